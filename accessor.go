@@ -0,0 +1,45 @@
+package dorm
+
+// FieldAccessor is implemented by Records with accessors generated by
+// `dormgen columns`. When the bound Record implements it, DbRecorder reads and
+// writes the fields through these methods instead of reflection.
+//
+// The three methods must list the columns in the same order. A pointer field
+// is referenced as a pointer to the pointer, and a nil pointer has a nil value.
+type FieldAccessor interface {
+	// DormColumns returns the column names of the Record.
+	DormColumns() []string
+	// DormFieldRefs returns references to the fields holding each column.
+	DormFieldRefs() []interface{}
+	// DormValues returns the current value of each column.
+	DormValues() []interface{}
+}
+
+// bindAccessor picks up the generated accessors of the bound Record. They are
+// only used if they cover every field found by scanFields, so a stale
+// generated file falls back to reflection instead of scanning into the wrong
+// fields.
+func (s *DbRecorder) bindAccessor() {
+	s.accessor, s.access = nil, nil
+
+	fa, ok := s.record.(FieldAccessor)
+	if !ok {
+		return
+	}
+
+	var index = make(map[string]int)
+	for i, col := range fa.DormColumns() {
+		index[col] = i
+	}
+
+	var access = make(map[*field]int, len(s.fields))
+	for _, f := range s.fields {
+		i, ok := index[f.column]
+		if !ok {
+			return
+		}
+		access[f] = i
+	}
+
+	s.accessor, s.access = fa, access
+}
@@ -0,0 +1,90 @@
+package dorm
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+// accessorCalls counts the calls of generated accessors.
+var accessorCalls int
+
+// generated has accessors as written by `dormgen columns`.
+type generated struct {
+	Id   int64   `orm:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Name string  `orm:"name"`
+	Note *string `orm:"note,NULL"`
+}
+
+func (r *generated) DormColumns() []string { return []string{"id", "name", "note"} }
+
+func (r *generated) DormFieldRefs() []interface{} {
+	accessorCalls++
+	return []interface{}{&r.Id, &r.Name, &r.Note}
+}
+
+func (r *generated) DormValues() []interface{} {
+	accessorCalls++
+	var note interface{}
+	if r.Note != nil {
+		note = r.Note
+	}
+	return []interface{}{r.Id, r.Name, note}
+}
+
+// stale has accessors that miss a field added since they were generated.
+type stale struct {
+	Id    int64  `orm:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Name  string `orm:"name"`
+	Extra string `orm:"extra"`
+}
+
+func (r *stale) DormColumns() []string { return []string{"id", "name"} }
+
+func (r *stale) DormFieldRefs() []interface{} {
+	accessorCalls++
+	return []interface{}{&r.Id, &r.Name}
+}
+
+func (r *stale) DormValues() []interface{} {
+	accessorCalls++
+	return []interface{}{r.Id, r.Name}
+}
+
+func TestFieldAccessor(t *testing.T) {
+	f, d := openFake(t, "mysql")
+	accessorCalls = 0
+	rec := &generated{Id: 7, Name: "bo"}
+	d.Bind("notes", rec)
+
+	if err := d.Update(); err != nil {
+		t.Fatal(err)
+	}
+	sql, args := f.last()
+	if sql != "UPDATE `notes` SET `name` = ? WHERE `id` = ?" || !reflect.DeepEqual(args, []driver.Value{"bo", int64(7)}) {
+		t.Errorf("Update ran %q %v", sql, args)
+	}
+
+	f.answer([]string{"name", "note"}, []driver.Value{"al", "hi"})
+	if err := d.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Name != "al" || rec.Note == nil || *rec.Note != "hi" {
+		t.Errorf("Load() = %+v", rec)
+	}
+	if accessorCalls == 0 {
+		t.Error("the accessors were not used")
+	}
+
+	accessorCalls = 0
+	d.Bind("notes", &stale{Id: 7, Extra: "x"})
+	if err := d.Update(); err != nil {
+		t.Fatal(err)
+	}
+	if accessorCalls != 0 {
+		t.Error("stale accessors were used")
+	}
+	if sql, _ := f.last(); sql != "UPDATE `notes` SET `extra` = ?, `name` = ? WHERE `id` = ?" {
+		t.Errorf("Update ran %q", sql)
+	}
+}
@@ -0,0 +1,103 @@
+package dorm
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// Null is the result of an aggregate, which is NULL (Valid is false) over no
+// rows. It has the fields of sql.Null[T], which needs Go 1.22.
+type Null[T any] struct {
+	V     T
+	Valid bool
+}
+
+// Sum returns SUM(column) over the rows matched by fn.
+//
+// The result is NULL (Valid is false) when no row matches. T is whatever the
+// column should be scanned into, usually int64 or float64:
+//
+// 	total, err := dorm.Sum[int64](d, "amount", func(q squirrel.SelectBuilder) squirrel.SelectBuilder {
+// 		return q.Where(squirrel.Eq{"status": "paid"})
+// 	})
+func Sum[T any](d Recorder, column string, fn WhereCountFunc) (Null[T], error) {
+	return aggregate[T](d, "SUM("+Quote(d.Driver(), column)+")", fn)
+}
+
+// Avg returns AVG(column) over the rows matched by fn, or NULL if no row matches.
+func Avg(d Recorder, column string, fn WhereCountFunc) (Null[float64], error) {
+	return aggregate[float64](d, "AVG("+Quote(d.Driver(), column)+")", fn)
+}
+
+// Min returns MIN(column) over the rows matched by fn, or NULL if no row matches.
+func Min[T any](d Recorder, column string, fn WhereCountFunc) (Null[T], error) {
+	return aggregate[T](d, "MIN("+Quote(d.Driver(), column)+")", fn)
+}
+
+// Max returns MAX(column) over the rows matched by fn, or NULL if no row matches.
+func Max[T any](d Recorder, column string, fn WhereCountFunc) (Null[T], error) {
+	return aggregate[T](d, "MAX("+Quote(d.Driver(), column)+")", fn)
+}
+
+func aggregate[T any](d Recorder, expr string, fn WhereCountFunc) (Null[T], error) {
+	q := d.Builder().Select(expr).From(Quote(d.Driver(), d.TableName()))
+	q = fn(q)
+
+	// database/sql leaves a pointer nil for NULL
+	var v *T
+	if err := queryRow(q).Scan(&v); err != nil || v == nil {
+		return Null[T]{}, err
+	}
+	return Null[T]{V: *v, Valid: true}, nil
+}
+
+// GroupBy runs `SELECT cols..., aggregates... FROM table GROUP BY cols...` and
+// scans each group into an R.
+//
+// Result columns are matched to the fields of R by name, the same way columns
+// of a bound Record are named ('orm' tag or snake case), so aggregates should
+// be aliased:
+//
+// 	type statusTotal struct {
+// 		Status string  `orm:"status"`
+// 		Orders int64   `orm:"orders"`
+// 		Amount float64 `orm:"amount"`
+// 	}
+// 	rows, err := dorm.GroupBy[statusTotal](d, []string{"status"},
+// 		[]string{"COUNT(*) AS orders", "SUM(amount) AS amount"}, fn)
+//
+// fn may add conditions, HAVING or ORDER BY clauses. R must be a struct; use
+// GroupByMap for a single aggregate.
+func GroupBy[R any](d Recorder, cols []string, aggregates []string, fn WhereCountFunc) ([]R, error) {
+	if rt := reflect.TypeOf((*R)(nil)).Elem(); rt.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("dorm: GroupBy needs a struct result type, got %s", rt)
+	}
+
+	var quoted = quoteAll(d.Driver(), cols)
+	var sel = make([]string, 0, len(cols)+len(aggregates))
+	sel = append(sel, quoted...)
+	sel = append(sel, aggregates...)
+
+	q := d.Builder().Select(sel...).From(Quote(d.Driver(), d.TableName())).GroupBy(quoted...)
+	q = fn(q)
+
+	rows, err := q.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRows[R](rows)
+}
+
+// GroupByMap groups the rows by a single column and returns the aggregate of
+// each group, keyed by the value of that column:
+//
+// 	counts, err := dorm.GroupByMap[string, int64](d, "status", "COUNT(*)", fn)
+func GroupByMap[K comparable, V any](d Recorder, column string, expr string, fn WhereCountFunc) (map[K]V, error) {
+	return PluckMap[K, V](d, column, expr, func(q squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return fn(q.GroupBy(Quote(d.Driver(), column)))
+	})
+}
@@ -0,0 +1,136 @@
+package dorm
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// order is aggregated by status.
+type order struct {
+	Id     int64   `orm:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Status string  `orm:"status"`
+	Amount float64 `orm:"amount"`
+}
+
+type statusTotal struct {
+	Status string  `orm:"status"`
+	Orders int64   `orm:"orders"`
+	Amount float64 `orm:"amount"`
+}
+
+func paidOnly(q squirrel.SelectBuilder) squirrel.SelectBuilder {
+	return q.Where(squirrel.Eq{"status": "paid"})
+}
+
+func TestAggregates(t *testing.T) {
+	tests := []struct {
+		name  string
+		value driver.Value
+		agg   func(d Recorder) (interface{}, bool, error)
+		sql   string
+		want  interface{}
+		valid bool
+	}{
+		{
+			name:  "sum",
+			value: int64(42),
+			agg: func(d Recorder) (interface{}, bool, error) {
+				v, err := Sum[int64](d, "amount", paidOnly)
+				return v.V, v.Valid, err
+			},
+			sql:  "SELECT SUM(`amount`) FROM `orders` WHERE status = ?",
+			want: int64(42), valid: true,
+		},
+		{
+			name:  "sum over no rows",
+			value: nil,
+			agg: func(d Recorder) (interface{}, bool, error) {
+				v, err := Sum[int64](d, "amount", paidOnly)
+				return v.V, v.Valid, err
+			},
+			sql:  "SELECT SUM(`amount`) FROM `orders` WHERE status = ?",
+			want: int64(0),
+		},
+		{
+			name:  "avg",
+			value: 2.5,
+			agg: func(d Recorder) (interface{}, bool, error) {
+				v, err := Avg(d, "amount", paidOnly)
+				return v.V, v.Valid, err
+			},
+			sql:  "SELECT AVG(`amount`) FROM `orders` WHERE status = ?",
+			want: 2.5, valid: true,
+		},
+		{
+			name:  "min",
+			value: "cancelled",
+			agg: func(d Recorder) (interface{}, bool, error) {
+				v, err := Min[string](d, "status", paidOnly)
+				return v.V, v.Valid, err
+			},
+			sql:  "SELECT MIN(`status`) FROM `orders` WHERE status = ?",
+			want: "cancelled", valid: true,
+		},
+		{
+			name:  "max over no rows",
+			value: nil,
+			agg: func(d Recorder) (interface{}, bool, error) {
+				v, err := Max[float64](d, "amount", paidOnly)
+				return v.V, v.Valid, err
+			},
+			sql:  "SELECT MAX(`amount`) FROM `orders` WHERE status = ?",
+			want: 0.0,
+		},
+	}
+	for _, tt := range tests {
+		f, d := openFake(t, "mysql")
+		d.Bind("orders", &order{})
+		f.answer([]string{"v"}, []driver.Value{tt.value})
+
+		got, valid, err := tt.agg(d)
+		if err != nil || got != tt.want || valid != tt.valid {
+			t.Errorf("%s: = %v, %v, %v, want %v, %v", tt.name, got, valid, err, tt.want, tt.valid)
+		}
+		if sql, _ := f.last(); sql != tt.sql {
+			t.Errorf("%s: ran %q, want %q", tt.name, sql, tt.sql)
+		}
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	f, d := openFake(t, "mysql")
+	d.Bind("orders", &order{})
+	f.answer([]string{"status", "orders", "amount", "extra"},
+		[]driver.Value{"paid", int64(3), 7.5, "ignored"},
+		[]driver.Value{"open", int64(1), 2.0, "ignored"})
+
+	got, err := GroupBy[statusTotal](d, []string{"status"}, []string{"COUNT(*) AS orders", "SUM(amount) AS amount"},
+		func(q squirrel.SelectBuilder) squirrel.SelectBuilder { return q.OrderBy("status") })
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []statusTotal{{"paid", 3, 7.5}, {"open", 1, 2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GroupBy() = %+v, want %+v", got, want)
+	}
+	if sql, _ := f.last(); sql != "SELECT `status`, COUNT(*) AS orders, SUM(amount) AS amount FROM `orders` GROUP BY `status` ORDER BY status" {
+		t.Errorf("GroupBy ran %q", sql)
+	}
+
+	var stmts = len(f.stmts)
+	if _, err := GroupBy[int64](d, []string{"status"}, []string{"COUNT(*)"}, paidOnly); err == nil || len(f.stmts) != stmts {
+		t.Errorf("GroupBy[int64]() = %v, ran %q", err, f.stmts[stmts:])
+	}
+
+	f.answer([]string{"status", "COUNT(*)"}, []driver.Value{"paid", int64(3)}, []driver.Value{"open", int64(1)})
+	counts, err := GroupByMap[string, int64](d, "status", "COUNT(*)", paidOnly)
+	if err != nil || !reflect.DeepEqual(counts, map[string]int64{"paid": 3, "open": 1}) {
+		t.Errorf("GroupByMap() = %v, %v", counts, err)
+	}
+	if sql, _ := f.last(); sql != "SELECT `status`, COUNT(*) FROM `orders` WHERE status = ? GROUP BY `status`" {
+		t.Errorf("GroupByMap ran %q", sql)
+	}
+}
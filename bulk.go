@@ -0,0 +1,177 @@
+package dorm
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// AllRows is a predicate that matches every row of the table.
+//
+// UpdateWhere and DeleteWhere refuse to run without a WHERE clause. Pass AllRows
+// as the predicate to state that a table-wide write is really intended.
+var AllRows squirrel.Sqlizer = allRows{}
+
+type allRows struct{}
+
+func (allRows) ToSql() (string, []interface{}, error) {
+	return "1=1", nil, nil
+}
+
+// UpdateWhere sets the given columns on every row matching the predicate and
+// returns the number of rows affected.
+//
+// The values of set may be plain values or squirrel expressions:
+//
+// 	n, err := dorm.UpdateWhere(d, map[string]interface{}{
+// 		"status":     "expired",
+// 		"updated_at": time.Now(),
+// 		"retries":    squirrel.Expr("retries + 1"),
+// 	}, "expires_at < ?", time.Now())
+//
+// The predicate follows Squirrel's Where(pred, args). An empty predicate returns
+// ErrEmptyPredicate; see AllRows. Rows that have been soft deleted (see Model)
+// are left untouched, unless the predicate is wrapped in IncludeDeleted.
+func UpdateWhere(d Recorder, set map[string]interface{}, pred interface{}, args ...interface{}) (int64, error) {
+	if len(set) == 0 {
+		return 0, errors.New("dorm: UpdateWhere needs at least one column to set")
+	}
+	var skipDeleted = true
+	if p, ok := pred.(includeDeleted); ok {
+		pred, args, skipDeleted = p.pred, p.args, false
+	}
+	if emptyPredicate(pred) {
+		return 0, ErrEmptyPredicate
+	}
+
+	q := d.Builder().Update(Quote(d.Driver(), d.TableName())).SetMap(quoteKeys(d.Driver(), set))
+	if pred != AllRows {
+		q = q.Where(pred, args...)
+	}
+	if deleted, _ := softDeleteColumns(d); deleted != "" && skipDeleted {
+		q = q.Where(squirrel.Eq{Quote(d.Driver(), deleted): false})
+	}
+
+	ret, err := q.Exec()
+	return rowsAffected(d, ret, err)
+}
+
+// IncludeDeleted makes UpdateWhere match the soft deleted rows as well, for
+// instance to restore them, or when the predicate tests "deleted" itself:
+//
+// 	n, err := dorm.UpdateWhere(d, map[string]interface{}{"deleted": false},
+// 		dorm.IncludeDeleted("id = ?", id))
+//
+// pred and args are as for UpdateWhere; used as a plain condition, the result
+// is pred alone.
+func IncludeDeleted(pred interface{}, args ...interface{}) squirrel.Sqlizer {
+	return includeDeleted{pred: pred, args: args}
+}
+
+type includeDeleted struct {
+	pred interface{}
+	args []interface{}
+}
+
+func (p includeDeleted) ToSql() (string, []interface{}, error) {
+	switch pred := p.pred.(type) {
+	case squirrel.Sqlizer:
+		return pred.ToSql()
+	case string:
+		return pred, p.args, nil
+	case map[string]interface{}:
+		return squirrel.Eq(pred).ToSql()
+	}
+	return "", nil, fmt.Errorf("dorm: unsupported predicate %T", p.pred)
+}
+
+// DeleteWhere deletes every row matching the predicate and returns the number
+// of rows affected.
+//
+// If the bound Record embeds Model (or otherwise has a "deleted" column), rows
+// are soft deleted: "deleted" is set to true and "deleted_at", when present, to
+// the current time. Use HardDeleteWhere to remove them for good.
+//
+// An empty predicate returns ErrEmptyPredicate; see AllRows.
+func DeleteWhere(d Recorder, pred interface{}, args ...interface{}) (int64, error) {
+	deleted, deletedAt := softDeleteColumns(d)
+	if deleted == "" {
+		return HardDeleteWhere(d, pred, args...)
+	}
+
+	set := map[string]interface{}{deleted: true}
+	if deletedAt != "" {
+		set[deletedAt] = time.Now()
+	}
+	return UpdateWhere(d, set, pred, args...)
+}
+
+// HardDeleteWhere runs a DELETE for every row matching the predicate, whether
+// or not it was soft deleted, and returns the number of rows affected.
+//
+// An empty predicate returns ErrEmptyPredicate; see AllRows.
+func HardDeleteWhere(d Recorder, pred interface{}, args ...interface{}) (int64, error) {
+	if emptyPredicate(pred) {
+		return 0, ErrEmptyPredicate
+	}
+
+	q := d.Builder().Delete(Quote(d.Driver(), d.TableName()))
+	if pred != AllRows {
+		q = q.Where(pred, args...)
+	}
+
+	ret, err := q.Exec()
+	return rowsAffected(d, ret, err)
+}
+
+func rowsAffected(d Recorder, ret sql.Result, err error) (int64, error) {
+	if err != nil {
+		return 0, TranslateError(d.Driver(), err)
+	}
+	return ret.RowsAffected()
+}
+
+// softDeleteColumns returns the soft delete columns declared on the bound
+// Record, or empty strings if it has none.
+func softDeleteColumns(d Recorder) (deleted, deletedAt string) {
+	for _, col := range d.Columns(true) {
+		switch col {
+		case "deleted":
+			deleted = col
+		case "deleted_at":
+			deletedAt = col
+		}
+	}
+	if deleted == "" {
+		return "", ""
+	}
+	return deleted, deletedAt
+}
+
+// emptyPredicate reports whether pred would produce no WHERE clause at all.
+func emptyPredicate(pred interface{}) bool {
+	switch p := pred.(type) {
+	case nil:
+		return true
+	case *Condition:
+		return p.expr == nil && p.err == nil
+	case string:
+		return strings.TrimSpace(p) == ""
+	case map[string]interface{}:
+		return len(p) == 0
+	case squirrel.Eq:
+		return len(p) == 0
+	case squirrel.And:
+		return len(p) == 0
+	case squirrel.Or:
+		return len(p) == 0
+	case squirrel.Sqlizer:
+		sql, _, err := p.ToSql()
+		return err == nil && strings.TrimSpace(sql) == ""
+	}
+	return false
+}
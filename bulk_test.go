@@ -0,0 +1,143 @@
+package dorm
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// post is soft deleted.
+type post struct {
+	Id        int64     `orm:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Title     string    `orm:"title"`
+	Deleted   bool      `orm:"deleted"`
+	DeletedAt time.Time `orm:"deleted_at"`
+}
+
+func TestBulkWrites(t *testing.T) {
+	tests := []struct {
+		name   string
+		table  string
+		record Record
+		write  func(d Recorder) (int64, error)
+		sql    string
+		nargs  int
+	}{
+		{
+			name:   "update",
+			table:  "accounts",
+			record: &account{},
+			write: func(d Recorder) (int64, error) {
+				return UpdateWhere(d, map[string]interface{}{"name": "x"}, "id > ?", 3)
+			},
+			sql:   "UPDATE `accounts` SET `name` = ? WHERE id > ?",
+			nargs: 2,
+		},
+		{
+			name:   "update skips soft deleted rows",
+			table:  "posts",
+			record: &post{},
+			write: func(d Recorder) (int64, error) {
+				return UpdateWhere(d, map[string]interface{}{"title": "x"}, squirrel.Eq{"id": 1})
+			},
+			sql:   "UPDATE `posts` SET `title` = ? WHERE id = ? AND `deleted` = ?",
+			nargs: 3,
+		},
+		{
+			name:   "update including soft deleted rows",
+			table:  "posts",
+			record: &post{},
+			write: func(d Recorder) (int64, error) {
+				return UpdateWhere(d, map[string]interface{}{"deleted": false}, IncludeDeleted("id = ?", 1))
+			},
+			sql:   "UPDATE `posts` SET `deleted` = ? WHERE id = ?",
+			nargs: 2,
+		},
+		{
+			name:   "update of all rows",
+			table:  "accounts",
+			record: &account{},
+			write: func(d Recorder) (int64, error) {
+				return UpdateWhere(d, map[string]interface{}{"name": squirrel.Expr("upper(name)")}, AllRows)
+			},
+			sql: "UPDATE `accounts` SET `name` = upper(name)",
+		},
+		{
+			name:   "delete",
+			table:  "accounts",
+			record: &account{},
+			write: func(d Recorder) (int64, error) {
+				return DeleteWhere(d, squirrel.Lt{"id": 10})
+			},
+			sql:   "DELETE FROM `accounts` WHERE id < ?",
+			nargs: 1,
+		},
+		{
+			name:   "soft delete",
+			table:  "posts",
+			record: &post{},
+			write: func(d Recorder) (int64, error) {
+				return DeleteWhere(d, "title = ?", "x")
+			},
+			sql:   "UPDATE `posts` SET `deleted_at` = ?, `deleted` = ? WHERE title = ? AND `deleted` = ?",
+			nargs: 4,
+		},
+		{
+			name:   "hard delete of a soft deleted table",
+			table:  "posts",
+			record: &post{},
+			write: func(d Recorder) (int64, error) {
+				return HardDeleteWhere(d, AllRows)
+			},
+			sql: "DELETE FROM `posts`",
+		},
+	}
+	for _, tt := range tests {
+		f, d := openFake(t, "mysql")
+		f.affected = 4
+		d.Bind(tt.table, tt.record)
+
+		n, err := tt.write(d)
+		if err != nil || n != 4 {
+			t.Errorf("%s: = %d, %v", tt.name, n, err)
+		}
+		sql, args := f.last()
+		if sql != tt.sql || len(args) != tt.nargs {
+			t.Errorf("%s: ran %q %v, want %q with %d arguments", tt.name, sql, args, tt.sql, tt.nargs)
+		}
+	}
+}
+
+func TestBulkWritesRefuseEmptyPredicates(t *testing.T) {
+	set := map[string]interface{}{"name": "x"}
+	for _, pred := range []interface{}{nil, "", "  ", squirrel.Eq{}, map[string]interface{}{}, squirrel.And{}, squirrel.Or{}} {
+		f, d := openFake(t, "mysql")
+		d.Bind("accounts", &account{})
+
+		if _, err := UpdateWhere(d, set, pred); !errors.Is(err, ErrEmptyPredicate) {
+			t.Errorf("UpdateWhere(%#v) = %v", pred, err)
+		}
+		if _, err := DeleteWhere(d, pred); !errors.Is(err, ErrEmptyPredicate) {
+			t.Errorf("DeleteWhere(%#v) = %v", pred, err)
+		}
+		if _, err := HardDeleteWhere(d, pred); !errors.Is(err, ErrEmptyPredicate) {
+			t.Errorf("HardDeleteWhere(%#v) = %v", pred, err)
+		}
+		if len(f.stmts) != 0 {
+			t.Errorf("%#v: ran %q", pred, f.stmts)
+		}
+	}
+
+	f, d := openFake(t, "mysql")
+	if _, err := UpdateWhere(d.Bind("posts", &post{}), set, IncludeDeleted("")); !errors.Is(err, ErrEmptyPredicate) {
+		t.Errorf("UpdateWhere(IncludeDeleted(\"\")) = %v", err)
+	}
+	if len(f.stmts) != 0 {
+		t.Errorf("ran %q", f.stmts)
+	}
+	if _, err := UpdateWhere(d.Bind("accounts", &account{}), nil, AllRows); err == nil {
+		t.Error("UpdateWhere without columns to set succeeded")
+	}
+}
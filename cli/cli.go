@@ -0,0 +1,150 @@
+// Package cli implements the dorm command line: schema export, migrations,
+// schema drift checks and code generation.
+//
+// The schema and diff commands work on models, which only the application
+// knows; so an application gets the full tool from a small main registering
+// them, built with the database drivers it uses:
+//
+// 	package main
+//
+// 	import (
+// 		"github.com/dengsibao/dorm"
+// 		"github.com/dengsibao/dorm/cli"
+// 		_ "github.com/go-sql-driver/mysql"
+//
+// 		"example.com/shop/models"
+// 	)
+//
+// 	func main() {
+// 		cli.Main(func(db *dorm.DB) {
+// 			db.Register(&models.User{}, &models.Order{})
+// 		})
+// 	}
+//
+// The dorm command (cmd/dorm) is the same tool without models.
+//
+// Connection settings come from the -driver and -dsn flags, which default to
+// the DORM_DRIVER and DORM_DSN environment variables.
+package cli
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/dengsibao/dorm"
+)
+
+const usage = `usage: dorm <command> [flags] [arguments]
+
+commands:
+  schema    print the CREATE TABLE statements of the models
+  diff      compare the models with the live database
+  migrate   apply, revert, list or create migrations
+  gen       generate structs from a database, or column constants from structs
+
+Run 'dorm <command> -h' for the flags of a command.
+`
+
+// ErrUsage is returned by Run and Gen for bad arguments, once the usage has
+// been printed.
+var ErrUsage = errors.New("usage")
+
+// Main runs the command line given by os.Args, and exits. register, which
+// may be nil, registers the models on the DB the commands use.
+func Main(register func(db *dorm.DB)) {
+	err := Run(os.Args[1:], register)
+	switch {
+	case err == nil:
+	case errors.Is(err, flag.ErrHelp):
+	case errors.Is(err, ErrUsage):
+		os.Exit(2)
+	default:
+		fmt.Fprintf(os.Stderr, "dorm: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// Run runs the command line given by args, without the program name. It
+// writes to the standard output and error, and returns the error of the
+// command.
+func Run(args []string, register func(db *dorm.DB)) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return ErrUsage
+	}
+
+	var c = &command{register: register}
+	switch args[0] {
+	case "schema":
+		return c.schema(args[1:])
+	case "diff":
+		return c.diff(args[1:])
+	case "migrate":
+		return c.migrate(args[1:])
+	case "gen":
+		return Gen(args[1:])
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(os.Stdout, usage)
+		return nil
+	default:
+		fmt.Fprintf(os.Stderr, "dorm: unknown command %q\n\n%s", args[0], usage)
+		return ErrUsage
+	}
+}
+
+// command holds the connection settings shared by the commands.
+type command struct {
+	register func(db *dorm.DB)
+	driver   string
+	dsn      string
+}
+
+// flags returns a flag set with the connection flags.
+func (c *command) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&c.driver, "driver", os.Getenv("DORM_DRIVER"), "database driver: mysql, postgres or sqlite3 (env DORM_DRIVER)")
+	fs.StringVar(&c.dsn, "dsn", os.Getenv("DORM_DSN"), "data source name (env DORM_DSN)")
+	return fs
+}
+
+// open opens the database given by the flags.
+func (c *command) open() (*sql.DB, error) {
+	if c.driver == "" || c.dsn == "" {
+		return nil, fmt.Errorf("-driver and -dsn are required")
+	}
+	return sql.Open(c.driver, c.dsn)
+}
+
+// models returns the registered models, bound to conn, which may be nil for
+// the commands that only need the dialect.
+func (c *command) models(conn *sql.DB) ([]*dorm.DbRecorder, error) {
+	var proxy squirrel.DBProxyBeginner
+	if conn != nil {
+		proxy = squirrel.NewStmtCacheProxy(conn)
+	}
+	db := dorm.NewDB(proxy, c.driver)
+	if c.register != nil {
+		c.register(db)
+	}
+
+	models := db.Models()
+	if len(models) == 0 {
+		return nil, fmt.Errorf("no models registered: run a main calling cli.Main with a register function")
+	}
+	return models, nil
+}
+
+// parse parses args, reporting bad flags as ErrUsage.
+func parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return ErrUsage
+	}
+	return nil
+}
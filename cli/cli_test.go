@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dengsibao/dorm"
+)
+
+type widget struct {
+	Id   int64  `orm:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Name string `orm:"name"`
+}
+
+func registerWidget(db *dorm.DB) {
+	db.RegisterTable("widgets", &widget{})
+}
+
+// capture runs fn with the standard output and error redirected, and returns
+// what it wrote to each.
+func capture(t *testing.T, fn func() error) (stdout, stderr string, err error) {
+	t.Helper()
+	var outs [2]string
+	var files = [2]**os.File{&os.Stdout, &os.Stderr}
+	var saved [2]*os.File
+	var done [2]chan string
+	for i, f := range files {
+		r, w, perr := os.Pipe()
+		if perr != nil {
+			t.Fatal(perr)
+		}
+		saved[i], *f = *f, w
+		done[i] = make(chan string)
+		go func(c chan string) {
+			b, _ := io.ReadAll(r)
+			c <- string(b)
+		}(done[i])
+	}
+	err = fn()
+	for i, f := range files {
+		(*f).Close()
+		*f = saved[i]
+		outs[i] = <-done[i]
+	}
+	return outs[0], outs[1], err
+}
+
+func TestRun(t *testing.T) {
+	t.Setenv("DORM_DRIVER", "")
+	t.Setenv("DORM_DSN", "")
+
+	tests := []struct {
+		name     string
+		args     []string
+		register func(db *dorm.DB)
+		err      error
+		errText  string
+		stdout   string
+		stderr   string
+	}{
+		{name: "no command", args: nil, err: ErrUsage, stderr: "usage: dorm <command>"},
+		{name: "unknown command", args: []string{"nope"}, err: ErrUsage, stderr: `unknown command "nope"`},
+		{name: "help", args: []string{"help"}, stdout: "commands:"},
+		{name: "bad flag", args: []string{"schema", "-nope"}, err: ErrUsage},
+		{name: "flag help", args: []string{"schema", "-h"}, err: flag.ErrHelp},
+		{name: "schema without models", args: []string{"schema"}, errText: "no models registered"},
+		{
+			name: "schema", args: []string{"schema"}, register: registerWidget,
+			stdout: "create table IF NOT EXISTS `widgets` (`id` bigint auto_increment",
+		},
+		{
+			name: "schema for postgres", args: []string{"schema", "-driver", "postgres"}, register: registerWidget,
+			stdout: `create table IF NOT EXISTS "widgets" ("id" bigserial`,
+		},
+		{name: "diff without connection", args: []string{"diff"}, errText: "-driver and -dsn are required"},
+		{name: "migrate without command", args: []string{"migrate"}, err: ErrUsage, stderr: "usage: dorm migrate"},
+		{name: "migrate without connection", args: []string{"migrate", "up"}, errText: "-driver and -dsn are required"},
+		{name: "gen without generator", args: []string{"gen"}, err: ErrUsage, stderr: "usage: dorm gen"},
+		{name: "unknown generator", args: []string{"gen", "nope"}, err: ErrUsage, stderr: `unknown gen command "nope"`},
+		{name: "gen help", args: []string{"gen", "help"}, stdout: "structs"},
+		{name: "gen structs without connection", args: []string{"gen", "structs", "-pkg", "m"}, errText: "-driver and -dsn are required"},
+	}
+	for _, tt := range tests {
+		stdout, stderr, err := capture(t, func() error { return Run(tt.args, tt.register) })
+		switch {
+		case tt.err != nil:
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s: Run() = %v, want %v", tt.name, err, tt.err)
+			}
+		case tt.errText != "":
+			if err == nil || !strings.Contains(err.Error(), tt.errText) {
+				t.Errorf("%s: Run() = %v, want %q", tt.name, err, tt.errText)
+			}
+		case err != nil:
+			t.Errorf("%s: Run() = %v", tt.name, err)
+		}
+		if !strings.Contains(stdout, tt.stdout) {
+			t.Errorf("%s: stdout %q, want %q", tt.name, stdout, tt.stdout)
+		}
+		if !strings.Contains(stderr, tt.stderr) {
+			t.Errorf("%s: stderr %q, want %q", tt.name, stderr, tt.stderr)
+		}
+	}
+}
+
+func TestEnvDefaults(t *testing.T) {
+	t.Setenv("DORM_DRIVER", "sqlite3")
+	t.Setenv("DORM_DSN", "file:test.db")
+	t.Setenv("DORM_MIGRATIONS", "")
+
+	var c command
+	fs := c.flags("test")
+	if err := parse(fs, nil); err != nil {
+		t.Fatal(err)
+	}
+	if c.driver != "sqlite3" || c.dsn != "file:test.db" {
+		t.Errorf("flags default to %q %q", c.driver, c.dsn)
+	}
+	if err := parse(c.flags("test"), []string{"-driver", "mysql"}); err != nil || c.driver != "mysql" || c.dsn != "file:test.db" {
+		t.Errorf("-driver gives %q %q, %v", c.driver, c.dsn, err)
+	}
+
+	if got := envOr("DORM_MIGRATIONS", "migrations"); got != "migrations" {
+		t.Errorf("envOr() of an empty variable = %q", got)
+	}
+	t.Setenv("DORM_MIGRATIONS", "db/migrations")
+	if got := envOr("DORM_MIGRATIONS", "migrations"); got != "db/migrations" {
+		t.Errorf("envOr() = %q", got)
+	}
+
+	stdout, _, err := capture(t, func() error { return Run([]string{"schema"}, registerWidget) })
+	if err != nil || !strings.Contains(stdout, `create table IF NOT EXISTS "widgets"`) {
+		t.Errorf("schema with DORM_DRIVER=sqlite3 = %v, %q", err, stdout)
+	}
+}
+
+func TestGenColumns(t *testing.T) {
+	dir := t.TempDir()
+	src := "package models\n\ntype Widget struct {\n\tId int64 `orm:\"id,PRIMARY_KEY\"`\n}\n"
+	if err := os.WriteFile(filepath.Join(dir, "models.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Gen([]string{"columns", "-o", "cols.go", dir}); err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.ReadFile(filepath.Join(dir, "cols.go"))
+	if err != nil || !strings.Contains(string(out), `WidgetColId = "id"`) {
+		t.Errorf("gen columns wrote %s, %v", out, err)
+	}
+
+	if _, _, err := capture(t, func() error { return Gen([]string{"columns", "-type"}) }); !errors.Is(err, ErrUsage) {
+		t.Errorf("Gen() with a missing flag value = %v, want ErrUsage", err)
+	}
+}
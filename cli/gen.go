@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dengsibao/dorm"
+	dormgen "github.com/dengsibao/dorm/gen"
+)
+
+const genUsage = `usage: dorm gen <command> [flags]
+
+commands:
+  structs   generate structs from the schema of a live database
+  columns   generate column constants and accessors for tagged structs
+
+Run 'dorm gen <command> -h' for the flags of a command.
+`
+
+// Gen runs a code generator: "structs" reads the schema of an existing
+// MySQL, Postgres or SQLite database and writes one tagged struct per table;
+// "columns" reads the structs of a Go package and writes table and column
+// name constants, plus accessors that let DbRecorder skip reflection. args
+// starts with the generator name.
+func Gen(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, genUsage)
+		return ErrUsage
+	}
+
+	switch args[0] {
+	case "structs":
+		return structs(args[1:])
+	case "columns":
+		return columns(args[1:])
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(os.Stdout, genUsage)
+		return nil
+	default:
+		fmt.Fprintf(os.Stderr, "dorm: unknown gen command %q\n\n%s", args[0], genUsage)
+		return ErrUsage
+	}
+}
+func structs(args []string) error {
+	var cmd command
+	fs := cmd.flags("structs")
+	tables := fs.String("tables", "", "comma separated tables to generate, default all")
+	pkg := fs.String("pkg", "models", "package name of the generated file")
+	out := fs.String("o", "", "output file, default stdout")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	db, err := cmd.open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var names []string
+	if *tables != "" {
+		names = strings.Split(*tables, ",")
+	} else if names, err = dorm.InspectTables(db, cmd.driver); err != nil {
+		return err
+	}
+
+	var list = make([]dormgen.Table, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		cols, err := dorm.InspectColumns(db, cmd.driver, name)
+		if err != nil {
+			return fmt.Errorf("inspecting %s: %v", name, err)
+		}
+		if len(cols) == 0 {
+			return fmt.Errorf("table %s not found", name)
+		}
+
+		t := dormgen.Table{Name: name, Columns: cols, Samples: make(map[string][]byte)}
+		for _, c := range cols {
+			if c.DataType != "json" && c.DataType != "jsonb" {
+				continue
+			}
+			if t.Samples[c.Name], err = sample(db, cmd.driver, name, c.Name); err != nil {
+				return fmt.Errorf("sampling %s.%s: %v", name, c.Name, err)
+			}
+		}
+		list = append(list, t)
+	}
+
+	src, err := dormgen.Structs(*pkg, list)
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(*out, src, 0644)
+}
+
+func columns(args []string) error {
+	fs := flag.NewFlagSet("columns", flag.ContinueOnError)
+	types := fs.String("type", "", "comma separated struct types, default every struct with orm tags")
+	out := fs.String("o", "dorm_columns_gen.go", "output file name, written to the package directory")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	var dir = "."
+	if fs.NArg() > 0 {
+		dir = fs.Arg(0)
+	}
+
+	var names []string
+	if *types != "" {
+		names = strings.Split(*types, ",")
+	}
+	return dormgen.WriteColumns(dir, names, *out)
+}
+
+// sample returns one non-NULL value of a column, or nil if there is none.
+func sample(db *sql.DB, driver, table, column string) ([]byte, error) {
+	var v []byte
+	table, column = dorm.Quote(driver, table), dorm.Quote(driver, column)
+	q := fmt.Sprintf("SELECT %s FROM %s WHERE %s IS NOT NULL LIMIT 1", column, table, column)
+	err := db.QueryRow(q).Scan(&v)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return v, err
+}
@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/dengsibao/dorm"
+	"github.com/dengsibao/dorm/migrate"
+)
+
+const migrateUsage = `usage: dorm migrate [flags] <command>
+
+commands:
+  up            apply every pending migration
+  down [n]      revert the last n migrations, 1 by default
+  to <version>  migrate up or down to version; 0 reverts everything
+  status        list the migrations and whether they are applied
+  create <name> write a new migration, from the differences between the
+                models and the database when models are registered
+
+flags:
+`
+
+// migrate runs the migrations of the -dir directory.
+func (c *command) migrate(args []string) error {
+	fs := c.flags("migrate")
+	dir := fs.String("dir", envOr("DORM_MIGRATIONS", "migrations"), "migrations directory (env DORM_MIGRATIONS)")
+	table := fs.String("table", migrate.DefaultTable, "table recording the applied versions")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), migrateUsage)
+		fs.PrintDefaults()
+	}
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return ErrUsage
+	}
+
+	conn, err := c.open()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if fs.Arg(0) == "create" {
+		// the first migration may start the directory
+		if err := os.MkdirAll(*dir, 0o755); err != nil {
+			return err
+		}
+	}
+	m, err := migrate.New(conn, c.driver, os.DirFS(*dir))
+	if err != nil {
+		return err
+	}
+	m.Table = *table
+	m.Logf = func(format string, args ...interface{}) {
+		fmt.Fprintf(os.Stdout, format+"\n", args...)
+	}
+
+	switch cmd, rest := fs.Arg(0), fs.Args()[1:]; cmd {
+	case "up":
+		return m.Up()
+
+	case "down":
+		var n = 1
+		if len(rest) > 0 {
+			if n, err = strconv.Atoi(rest[0]); err != nil || n < 1 {
+				return fmt.Errorf("migrate down: bad count %q", rest[0])
+			}
+		}
+		return m.Down(n)
+
+	case "to":
+		if len(rest) != 1 {
+			return fmt.Errorf("migrate to: a version is required")
+		}
+		version, err := strconv.ParseInt(rest[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("migrate to: bad version %q", rest[0])
+		}
+		return m.To(version)
+
+	case "status":
+		return status(m)
+
+	case "create":
+		if len(rest) != 1 {
+			return fmt.Errorf("migrate create: a name is required")
+		}
+		var models []*dorm.DbRecorder
+		if c.register != nil {
+			if models, err = c.models(conn); err != nil {
+				return err
+			}
+		}
+		path, err := m.Create(*dir, rest[0], models...)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stdout, "created", path)
+		return nil
+
+	default:
+		fs.Usage()
+		return ErrUsage
+	}
+}
+
+func status(m *migrate.Migrator) error {
+	st, err := m.Status()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+	for _, s := range st {
+		var applied = "pending"
+		if s.Applied {
+			applied = s.AppliedAt.String()
+		}
+		if s.Missing {
+			applied += " (no files)"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, applied)
+	}
+	return w.Flush()
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
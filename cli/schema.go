@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dengsibao/dorm"
+)
+
+// schema prints the CREATE TABLE statements of the models. It needs no
+// connection: -driver only picks the dialect.
+func (c *command) schema(args []string) error {
+	fs := c.flags("schema")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if c.driver == "" {
+		c.driver = "mysql"
+	}
+
+	models, err := c.models(nil)
+	if err != nil {
+		return err
+	}
+	for i, m := range models {
+		if i > 0 {
+			fmt.Fprintln(os.Stdout)
+		}
+		fmt.Fprintln(os.Stdout, m.GetSchema())
+	}
+	return nil
+}
+
+// diff compares the models with the live tables, see dorm.VerifySchema, and
+// fails when they differ. With -sql, it prints the statements adding the
+// missing tables and columns instead.
+func (c *command) diff(args []string) error {
+	fs := c.flags("diff")
+	statements := fs.Bool("sql", false, "print the statements creating the missing tables and columns")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	conn, err := c.open()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	models, err := c.models(conn)
+	if err != nil {
+		return err
+	}
+
+	if *statements {
+		for _, m := range models {
+			live, err := dorm.InspectColumns(conn, c.driver, m.TableName())
+			if err != nil {
+				return fmt.Errorf("inspecting %s: %v", m.TableName(), err)
+			}
+			up, _ := m.SchemaDiff(live)
+			for _, stmt := range up {
+				fmt.Fprintln(os.Stdout, stmt)
+			}
+		}
+		return nil
+	}
+
+	var recorders = make([]dorm.Recorder, len(models))
+	for i, m := range models {
+		recorders[i] = m
+	}
+	report, err := dorm.VerifySchema(recorders...)
+	if err != nil {
+		return err
+	}
+	for _, issue := range report.Issues {
+		fmt.Fprintln(os.Stdout, issue)
+	}
+	if !report.OK() {
+		return fmt.Errorf("%d schema differences", len(report.Issues))
+	}
+	fmt.Fprintln(os.Stdout, "schema up to date")
+	return nil
+}
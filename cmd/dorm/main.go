@@ -0,0 +1,27 @@
+// Command dorm manages the schema of a dorm application.
+//
+// Usage:
+//
+// 	dorm migrate -driver postgres -dsn 'postgres://localhost/shop' up
+// 	dorm migrate -dir db/migrations status
+// 	dorm gen structs -driver mysql -dsn 'user:pass@tcp(localhost:3306)/shop' -pkg models
+// 	dorm gen columns -type User,Order
+//
+// The driver and dsn default to the DORM_DRIVER and DORM_DSN environment
+// variables, the migrations directory to DORM_MIGRATIONS or ./migrations.
+//
+// This build knows no models, so schema and diff are only available from a
+// main of the application registering its models, see package cli.
+package main
+
+import (
+	"github.com/dengsibao/dorm/cli"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func main() {
+	cli.Main(nil)
+}
@@ -0,0 +1,43 @@
+// Command dormgen generates Go code for dorm. It is the gen command of the
+// dorm tool, see cmd/dorm.
+//
+// Usage:
+//
+// 	dormgen structs -driver mysql -dsn 'user:pass@tcp(localhost:3306)/shop' -pkg models -o models.go
+// 	dormgen columns -type User,Order
+//
+// The structs subcommand reads the schema of an existing MySQL, Postgres or
+// SQLite database and writes one tagged struct per table. The driver and dsn
+// default to the DORM_DRIVER and DORM_DSN environment variables.
+//
+// The columns subcommand reads the structs of the Go package in the current
+// directory and writes table and column name constants, plus accessors that
+// let DbRecorder skip reflection. It is meant for go generate:
+//
+// 	//go:generate go run github.com/dengsibao/dorm/cmd/dormgen columns -type User
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/dengsibao/dorm/cli"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func main() {
+	err := cli.Gen(os.Args[1:])
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+	case errors.Is(err, cli.ErrUsage):
+		os.Exit(2)
+	default:
+		fmt.Fprintf(os.Stderr, "dormgen: %v\n", err)
+		os.Exit(1)
+	}
+}
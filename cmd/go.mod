@@ -0,0 +1,20 @@
+module github.com/dengsibao/dorm/cmd
+
+go 1.18
+
+require (
+	github.com/dengsibao/dorm v0.0.0-00010101000000-000000000000
+	github.com/go-sql-driver/mysql v1.8.1
+	github.com/lib/pq v1.10.9
+	github.com/mattn/go-sqlite3 v1.14.19
+)
+
+require (
+	filippo.io/edwards25519 v1.1.1 // indirect
+	github.com/Masterminds/squirrel v1.5.2 // indirect
+	github.com/lann/builder v0.0.0-20180802200727-47ae307949d0 // indirect
+	github.com/lann/ps v0.0.0-20150810152359-62de8c46ede0 // indirect
+)
+
+// The commands are developed along with the library.
+replace github.com/dengsibao/dorm => ../
@@ -0,0 +1,230 @@
+package dorm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// Condition is a WHERE condition whose columns are checked against the fields
+// of a bound Record. It implements squirrel.Sqlizer, so it can be passed
+// anywhere a predicate is expected:
+//
+// 	cond := dorm.Where(d).Col("email").Eq(email).And(d.Col("age").Gte(18))
+// 	err := d.LoadWhere(cond)
+//
+// A column that the Record does not have makes ToSql fail, so the mistake is
+// reported before any SQL reaches the database. Conditions are immutable;
+// combining them returns a new Condition.
+type Condition struct {
+	d    Recorder
+	expr squirrel.Sqlizer
+	err  error
+}
+
+// Column refers to a column of a bound Record, to build a Condition on it.
+type Column struct {
+	d      Recorder
+	name   string
+	err    error
+	parent *Condition
+}
+
+// Where starts an empty Condition on the columns of d.
+func Where(d Recorder) *Condition {
+	return &Condition{d: d}
+}
+
+// Col returns the named column of d. The name may be qualified with the table
+// name of d, as in users.email.
+func Col(d Recorder, name string) *Column {
+	return &Column{d: d, name: name, err: checkColumn(d, name)}
+}
+
+// Col returns the named column of the bound Record. See dorm.Col.
+func (s *DbRecorder) Col(name string) *Column {
+	return Col(s, name)
+}
+
+// checkColumn returns an error wrapping ErrUnknownColumn if d has no such column.
+func checkColumn(d Recorder, name string) error {
+	col := name
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		if name[:i] != d.TableName() {
+			return fmt.Errorf("%w: %q is not a column of %s", ErrUnknownColumn, name, d.TableName())
+		}
+		col = name[i+1:]
+	}
+	for _, c := range d.Columns(true) {
+		if c == col {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q is not a column of %s", ErrUnknownColumn, name, d.TableName())
+}
+
+// Col returns the named column, whose condition will be ANDed to c.
+func (c *Condition) Col(name string) *Column {
+	col := Col(c.d, name)
+	col.parent = c
+	return col
+}
+
+// And returns a Condition matching c and every one of others.
+func (c *Condition) And(others ...*Condition) *Condition {
+	return c.join(false, others)
+}
+
+// Or returns a Condition matching c or any one of others.
+func (c *Condition) Or(others ...*Condition) *Condition {
+	return c.join(true, others)
+}
+
+// Not returns the negation of c.
+func (c *Condition) Not() *Condition {
+	if c.expr == nil {
+		return c
+	}
+	return &Condition{d: c.d, expr: squirrel.Expr("NOT (?)", c.expr), err: c.err}
+}
+
+func (c *Condition) join(or bool, others []*Condition) *Condition {
+	var parts []squirrel.Sqlizer
+	var err = c.err
+	for _, o := range append([]*Condition{c}, others...) {
+		if err == nil {
+			err = o.err
+		}
+		if o.expr != nil {
+			parts = append(parts, o.expr)
+		}
+	}
+
+	next := &Condition{d: c.d, err: err}
+	switch {
+	case len(parts) == 1:
+		next.expr = parts[0]
+	case len(parts) > 1 && or:
+		next.expr = squirrel.Or(parts)
+	case len(parts) > 1:
+		next.expr = squirrel.And(parts)
+	}
+	return next
+}
+
+// Err returns the first error met while building the Condition, such as an
+// unknown column.
+func (c *Condition) Err() error {
+	return c.err
+}
+
+// ToSql implements squirrel.Sqlizer. An empty Condition matches every row.
+func (c *Condition) ToSql() (string, []interface{}, error) {
+	if c.err != nil {
+		return "", nil, c.err
+	}
+	if c.expr == nil {
+		return "(1=1)", nil, nil
+	}
+	return c.expr.ToSql()
+}
+
+// Apply adds the Condition to a query. It is a WhereFunc:
+//
+// 	list, err := dorm.ListWhere(d, nil, cond.Apply)
+func (c *Condition) Apply(q squirrel.SelectBuilder) squirrel.SelectBuilder {
+	if c.expr == nil && c.err == nil {
+		return q
+	}
+	return q.Where(c)
+}
+
+// quoted returns the column name quoted for the database, see Quote.
+func (c *Column) quoted() string {
+	return Quote(c.d.Driver(), c.name)
+}
+
+func (c *Column) cond(expr squirrel.Sqlizer) *Condition {
+	next := &Condition{d: c.d, expr: expr, err: c.err}
+	if c.parent != nil {
+		return c.parent.And(next)
+	}
+	return next
+}
+
+// Eq matches rows where the column equals v. A nil v matches NULL, and a slice
+// matches any of its elements (IN).
+func (c *Column) Eq(v interface{}) *Condition {
+	return c.cond(squirrel.Eq{c.quoted(): v})
+}
+
+// NotEq matches rows where the column differs from v.
+func (c *Column) NotEq(v interface{}) *Condition {
+	return c.cond(squirrel.NotEq{c.quoted(): v})
+}
+
+// Gt matches rows where the column is greater than v.
+func (c *Column) Gt(v interface{}) *Condition {
+	return c.cond(squirrel.Gt{c.quoted(): v})
+}
+
+// Gte matches rows where the column is greater than or equal to v.
+func (c *Column) Gte(v interface{}) *Condition {
+	return c.cond(squirrel.GtOrEq{c.quoted(): v})
+}
+
+// Lt matches rows where the column is less than v.
+func (c *Column) Lt(v interface{}) *Condition {
+	return c.cond(squirrel.Lt{c.quoted(): v})
+}
+
+// Lte matches rows where the column is less than or equal to v.
+func (c *Column) Lte(v interface{}) *Condition {
+	return c.cond(squirrel.LtOrEq{c.quoted(): v})
+}
+
+// Like matches rows where the column matches the LIKE pattern.
+func (c *Column) Like(pattern string) *Condition {
+	return c.cond(squirrel.Like{c.quoted(): pattern})
+}
+
+// Contains matches rows where the column contains s. Unlike a Like pattern, s
+// is taken literally: its % and _ match themselves.
+func (c *Column) Contains(s string) *Condition {
+	return c.cond(squirrel.Expr(c.quoted()+" LIKE ? ESCAPE '!'", "%"+likeEscaper.Replace(s)+"%"))
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern with '!', which, unlike
+// the backslash, is written the same in the string literals of every flavor.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// NotLike matches rows where the column does not match the LIKE pattern.
+func (c *Column) NotLike(pattern string) *Condition {
+	return c.cond(squirrel.NotLike{c.quoted(): pattern})
+}
+
+// In matches rows where the column is one of the elements of the slice values.
+func (c *Column) In(values interface{}) *Condition {
+	return c.cond(squirrel.Eq{c.quoted(): values})
+}
+
+// NotIn matches rows where the column is none of the elements of the slice values.
+func (c *Column) NotIn(values interface{}) *Condition {
+	return c.cond(squirrel.NotEq{c.quoted(): values})
+}
+
+// Between matches rows where the column lies between from and to, inclusive.
+func (c *Column) Between(from, to interface{}) *Condition {
+	return c.cond(squirrel.Expr(c.quoted()+" BETWEEN ? AND ?", from, to))
+}
+
+// IsNull matches rows where the column is NULL.
+func (c *Column) IsNull() *Condition {
+	return c.cond(squirrel.Eq{c.quoted(): nil})
+}
+
+// IsNotNull matches rows where the column is not NULL.
+func (c *Column) IsNotNull() *Condition {
+	return c.cond(squirrel.NotEq{c.quoted(): nil})
+}
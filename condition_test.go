@@ -0,0 +1,88 @@
+package dorm
+
+import (
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCondition(t *testing.T) {
+	_, d := openFake(t, "mysql")
+	d.Bind("orders", &order{})
+
+	tests := []struct {
+		name string
+		cond *Condition
+		sql  string
+		args []interface{}
+	}{
+		{name: "empty", cond: Where(d), sql: "(1=1)"},
+		{name: "eq", cond: Where(d).Col("status").Eq("paid"), sql: "`status` = ?", args: []interface{}{"paid"}},
+		{name: "qualified", cond: d.Col("orders.amount").Gt(10), sql: "`orders`.`amount` > ?", args: []interface{}{10}},
+		{name: "null", cond: d.Col("status").IsNull(), sql: "`status` IS NULL"},
+		{name: "not null", cond: d.Col("status").IsNotNull(), sql: "`status` IS NOT NULL"},
+		{name: "in", cond: d.Col("id").In([]int64{1, 2}), sql: "`id` IN (?,?)", args: []interface{}{int64(1), int64(2)}},
+		{name: "not in", cond: d.Col("id").NotIn([]int64{3}), sql: "`id` NOT IN (?)", args: []interface{}{int64(3)}},
+		{name: "between", cond: d.Col("amount").Between(1, 5), sql: "`amount` BETWEEN ? AND ?", args: []interface{}{1, 5}},
+		{name: "like", cond: d.Col("status").Like("pa%"), sql: "`status` LIKE ?", args: []interface{}{"pa%"}},
+		{
+			name: "chained columns are ANDed",
+			cond: Where(d).Col("status").NotEq("open").Col("amount").Lte(5),
+			sql:  "(`status` <> ? AND `amount` <= ?)", args: []interface{}{"open", 5},
+		},
+		{
+			name: "or",
+			cond: d.Col("amount").Lt(1).Or(d.Col("amount").Gte(100), Where(d)),
+			sql:  "(`amount` < ? OR `amount` >= ?)", args: []interface{}{1, 100},
+		},
+		{
+			name: "not",
+			cond: d.Col("status").NotLike("x%").And(d.Col("id").Eq(1)).Not(),
+			sql:  "NOT ((`status` NOT LIKE ? AND `id` = ?))", args: []interface{}{"x%", 1},
+		},
+	}
+	for _, tt := range tests {
+		sql, args, err := tt.cond.ToSql()
+		if err != nil || sql != tt.sql || !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("%s: ToSql() = %q %v %v, want %q %v", tt.name, sql, args, err, tt.sql, tt.args)
+		}
+	}
+
+	for _, cond := range []*Condition{
+		d.Col("nope").Eq(1),
+		d.Col("customers.id").Eq(1),
+		Where(d).Col("status").Eq("paid").Col("nope").Eq(1),
+		d.Col("status").Eq("paid").Or(d.Col("nope").Eq(1)),
+	} {
+		if _, _, err := cond.ToSql(); !errors.Is(err, ErrUnknownColumn) || !errors.Is(cond.Err(), ErrUnknownColumn) {
+			t.Errorf("ToSql() = %v, want ErrUnknownColumn", err)
+		}
+	}
+}
+
+func TestConditionIsNotSentWithUnknownColumns(t *testing.T) {
+	f, d := openFake(t, "mysql")
+	d.Bind("orders", &order{})
+
+	if err := d.LoadWhere(d.Col("nope").Eq(1)); !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("LoadWhere() = %v, want ErrUnknownColumn", err)
+	}
+	if _, err := Count(d, d.Col("nope").Eq(1).Apply); !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("Count() = %v, want ErrUnknownColumn", err)
+	}
+	if _, err := DeleteWhere(d, Where(d)); !errors.Is(err, ErrEmptyPredicate) {
+		t.Errorf("DeleteWhere() of an empty Condition = %v, want ErrEmptyPredicate", err)
+	}
+	if len(f.stmts) != 0 {
+		t.Errorf("ran %q", f.stmts)
+	}
+
+	f.answer([]string{"n"}, []driver.Value{int64(0)})
+	if _, err := Count(d, Where(d).Apply); err != nil {
+		t.Fatal(err)
+	}
+	if sql, _ := f.last(); sql != "SELECT COUNT(*) FROM `orders`" {
+		t.Errorf("Count() of an empty Condition ran %q", sql)
+	}
+}
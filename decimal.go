@@ -0,0 +1,362 @@
+package dorm
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an arbitrary precision fixed-point number, for decimal columns
+// holding amounts that a float64 would round:
+//
+// 	type Invoice struct {
+// 		Id    int64        `orm:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+// 		Total dorm.Decimal `orm:"total" precision:"18" scale:"2"`
+// 	}
+//
+// The precision and scale tags set the decimal(p,s) type in GetSchema; they
+// default to decimal(12,4). A Decimal keeps its scale: 1.50 prints as 1.50.
+// It is written to the database and to JSON as a string, so no digit is lost
+// on the way. The zero value is 0.
+type Decimal struct {
+	coef  *big.Int
+	scale int32
+}
+
+// NewDecimal returns value * 10^-scale: NewDecimal(1999, 2) is 19.99.
+func NewDecimal(value int64, scale int32) Decimal {
+	return Decimal{coef: big.NewInt(value), scale: scale}
+}
+
+// DecimalFromFloat returns the shortest decimal representing f. NaN and the
+// infinities are an error.
+func DecimalFromFloat(f float64) (Decimal, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Decimal{}, fmt.Errorf("dorm: cannot convert %v to a Decimal", f)
+	}
+	return ParseDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+// Bounds of ParseDecimal, which often reads untrusted input such as JSON
+// bodies: 1e-50000000 would otherwise make every Add on it allocate megabytes.
+// Both are far beyond what a database column holds.
+const (
+	maxDecimalDigits = 1000
+	maxDecimalScale  = 1000
+)
+
+// ParseDecimal parses a decimal number such as -12.50 or 1.5e3. It fails on
+// more than 1000 digits, or more than 1000 of them after the point, once the
+// exponent is applied.
+func ParseDecimal(s string) (Decimal, error) {
+	var in = strings.TrimSpace(s)
+	var exp int64
+	if i := strings.IndexAny(in, "eE"); i >= 0 {
+		var err error
+		if exp, err = strconv.ParseInt(in[i+1:], 10, 32); err != nil {
+			return Decimal{}, fmt.Errorf("dorm: cannot parse decimal %q", s)
+		}
+		in = in[:i]
+	}
+
+	var scale int64
+	if i := strings.IndexByte(in, '.'); i >= 0 {
+		scale = int64(len(in) - i - 1)
+		in = in[:i] + in[i+1:]
+	}
+
+	var coef = new(big.Int)
+	if in == "" || in == "+" || in == "-" || strings.ContainsAny(in[1:], "+-") {
+		return Decimal{}, fmt.Errorf("dorm: cannot parse decimal %q", s)
+	}
+
+	scale -= exp
+	var digits = int64(len(strings.TrimLeft(in, "+-")))
+	if scale < 0 {
+		digits -= scale
+	}
+	if digits > maxDecimalDigits || scale > maxDecimalScale {
+		return Decimal{}, fmt.Errorf("dorm: decimal %q exceeds %d digits or %d decimal places", s, maxDecimalDigits, maxDecimalScale)
+	}
+	if _, ok := coef.SetString(in, 10); !ok {
+		return Decimal{}, fmt.Errorf("dorm: cannot parse decimal %q", s)
+	}
+	if scale < 0 {
+		coef.Mul(coef, pow10(-scale))
+		scale = 0
+	}
+	return Decimal{coef: coef, scale: int32(scale)}, nil
+}
+
+// MustDecimal is ParseDecimal, panicking on error. It is meant for constants.
+func MustDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func pow10(n int64) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(n), nil)
+}
+
+func (d Decimal) int() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+	return d.coef
+}
+
+// Scale returns the number of digits after the decimal point.
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// rescale returns d with a larger scale, without rounding.
+func (d Decimal) rescale(scale int32) *big.Int {
+	if scale <= d.scale {
+		return d.int()
+	}
+	return new(big.Int).Mul(d.int(), pow10(int64(scale-d.scale)))
+}
+
+// align returns the coefficients of d and e at their largest scale.
+func (d Decimal) align(e Decimal) (*big.Int, *big.Int, int32) {
+	scale := d.scale
+	if e.scale > scale {
+		scale = e.scale
+	}
+	return d.rescale(scale), e.rescale(scale), scale
+}
+
+// Add returns d + e.
+func (d Decimal) Add(e Decimal) Decimal {
+	a, b, scale := d.align(e)
+	return Decimal{coef: new(big.Int).Add(a, b), scale: scale}
+}
+
+// Sub returns d - e.
+func (d Decimal) Sub(e Decimal) Decimal {
+	a, b, scale := d.align(e)
+	return Decimal{coef: new(big.Int).Sub(a, b), scale: scale}
+}
+
+// Mul returns d * e, at the sum of both scales.
+func (d Decimal) Mul(e Decimal) Decimal {
+	return Decimal{coef: new(big.Int).Mul(d.int(), e.int()), scale: d.scale + e.scale}
+}
+
+// Div returns d / e rounded half away from zero to scale digits. It panics if
+// e is zero.
+func (d Decimal) Div(e Decimal, scale int32) Decimal {
+	if e.IsZero() {
+		panic("dorm: decimal division by zero")
+	}
+	num := new(big.Int).Set(d.int())
+	den := new(big.Int).Set(e.int())
+	// d/e = num/den * 10^(e.scale-d.scale), wanted at 10^-scale
+	if shift := int64(scale) + int64(e.scale) - int64(d.scale); shift >= 0 {
+		num.Mul(num, pow10(shift))
+	} else {
+		den.Mul(den, pow10(-shift))
+	}
+	return Decimal{coef: quoRound(num, den), scale: scale}
+}
+
+// quoRound returns num/den rounded half away from zero.
+func quoRound(num, den *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+	if new(big.Int).Mul(new(big.Int).Abs(r), big.NewInt(2)).Cmp(new(big.Int).Abs(den)) >= 0 {
+		if num.Sign() == den.Sign() {
+			q.Add(q, big.NewInt(1))
+		} else {
+			q.Sub(q, big.NewInt(1))
+		}
+	}
+	return q
+}
+
+// Round returns d rounded half away from zero to scale digits.
+func (d Decimal) Round(scale int32) Decimal {
+	if scale >= d.scale {
+		return Decimal{coef: d.rescale(scale), scale: scale}
+	}
+	return Decimal{coef: quoRound(d.int(), pow10(int64(d.scale-scale))), scale: scale}
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	return Decimal{coef: new(big.Int).Neg(d.int()), scale: d.scale}
+}
+
+// Abs returns |d|.
+func (d Decimal) Abs() Decimal {
+	return Decimal{coef: new(big.Int).Abs(d.int()), scale: d.scale}
+}
+
+// Cmp returns -1, 0 or +1 as d is less than, equal to or greater than e.
+func (d Decimal) Cmp(e Decimal) int {
+	a, b, _ := d.align(e)
+	return a.Cmp(b)
+}
+
+// Equal reports whether d and e are the same number, whatever their scales.
+func (d Decimal) Equal(e Decimal) bool {
+	return d.Cmp(e) == 0
+}
+
+// Sign returns -1, 0 or +1 as d is negative, zero or positive.
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+// IsZero reports whether d is 0.
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Float64 returns the nearest float64.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+func (d Decimal) String() string {
+	var digits = d.int().String()
+	var sign = ""
+	if digits[0] == '-' {
+		sign, digits = "-", digits[1:]
+	}
+	if d.scale <= 0 {
+		return sign + digits + strings.Repeat("0", int(-d.scale))
+	}
+	if len(digits) <= int(d.scale) {
+		digits = strings.Repeat("0", int(d.scale)-len(digits)+1) + digits
+	}
+	point := len(digits) - int(d.scale)
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// MarshalJSON writes the decimal as a JSON string.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON reads a JSON string or number.
+func (d *Decimal) UnmarshalJSON(data []byte) (err error) {
+	var v = string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+	}
+	*d, err = ParseDecimal(v)
+	return err
+}
+
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Decimal) UnmarshalText(data []byte) (err error) {
+	*d, err = ParseDecimal(string(data))
+	return err
+}
+
+// Value implements driver.Valuer, as a string.
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// Scan implements sql.Scanner. NULL is an error; use NullDecimal for nullable
+// columns.
+func (d *Decimal) Scan(input interface{}) (err error) {
+	switch v := input.(type) {
+	case nil:
+		return fmt.Errorf("dorm: cannot scan NULL into a Decimal, use NullDecimal")
+	case []byte:
+		*d, err = ParseDecimal(string(v))
+	case string:
+		*d, err = ParseDecimal(v)
+	case int64:
+		*d = NewDecimal(v, 0)
+	case float64:
+		*d, err = DecimalFromFloat(v)
+	default:
+		return fmt.Errorf("dorm: cannot scan %T into a Decimal", input)
+	}
+	return err
+}
+
+// NullDecimal is a Decimal that may be NULL.
+type NullDecimal struct {
+	Decimal Decimal
+	Valid   bool
+}
+
+func (n NullDecimal) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return json.Marshal(nil)
+	}
+	return n.Decimal.MarshalJSON()
+}
+
+func (n *NullDecimal) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*n = NullDecimal{}
+		return nil
+	}
+	if err := n.Decimal.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// Value implements driver.Valuer.
+func (n NullDecimal) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.Decimal.Value()
+}
+
+// Scan implements sql.Scanner.
+func (n *NullDecimal) Scan(input interface{}) error {
+	if input == nil {
+		*n = NullDecimal{}
+		return nil
+	}
+	if err := n.Decimal.Scan(input); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+var (
+	decimalType     = reflect.TypeOf(Decimal{})
+	nullDecimalType = reflect.TypeOf(NullDecimal{})
+)
+
+// decimalColumn returns the decimal(p,s) type given by the precision and scale
+// tags of a field.
+func decimalColumn(tag reflect.StructTag) string {
+	var precision, scale = tag.Get(TagPrecision), tag.Get(TagScale)
+	if precision == "" {
+		precision = "12"
+	}
+	if scale == "" {
+		scale = "4"
+	}
+	return fmt.Sprintf("decimal(%s,%s)", precision, scale)
+}
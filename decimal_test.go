@@ -0,0 +1,132 @@
+package dorm
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in, want string
+		fail     bool
+	}{
+		{in: "0", want: "0"},
+		{in: "-12.50", want: "-12.50"},
+		{in: "+3.1", want: "3.1"},
+		{in: " 7 ", want: "7"},
+		{in: ".5", want: "0.5"},
+		{in: "1.5e3", want: "1500"},
+		{in: "15E-3", want: "0.015"},
+		{in: "1e1000", fail: true},
+		{in: "1e999", want: "1" + strings.Repeat("0", 999)},
+		{in: "1e-1000", want: "0." + strings.Repeat("0", 999) + "1"},
+		{in: "1e-1001", fail: true},
+		{in: "1e-50000000", fail: true},
+		{in: "1e2000000000", fail: true},
+		{in: "1e99999999999", fail: true},
+		{in: strings.Repeat("9", 1001), fail: true},
+		{in: "", fail: true},
+		{in: "-", fail: true},
+		{in: "1.2.3", fail: true},
+		{in: "1-2", fail: true},
+		{in: "abc", fail: true},
+		{in: "1e", fail: true},
+	}
+	for _, tt := range tests {
+		d, err := ParseDecimal(tt.in)
+		if tt.fail {
+			if err == nil {
+				t.Errorf("ParseDecimal(%q) = %s, want an error", tt.in, d)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseDecimal(%q): %v", tt.in, err)
+		} else if d.String() != tt.want {
+			t.Errorf("ParseDecimal(%q) = %s, want %s", tt.in, d, tt.want)
+		}
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	tests := []struct {
+		name string
+		got  Decimal
+		want string
+	}{
+		{"add aligns scales", MustDecimal("1.5").Add(MustDecimal("0.25")), "1.75"},
+		{"sub", MustDecimal("1").Sub(MustDecimal("0.01")), "0.99"},
+		{"mul sums scales", MustDecimal("1.5").Mul(MustDecimal("0.20")), "0.300"},
+		{"div rounds half up", MustDecimal("2").Div(MustDecimal("3"), 2), "0.67"},
+		{"div rounds away from zero", MustDecimal("-1").Div(MustDecimal("8"), 2), "-0.13"},
+		{"div to a smaller scale", MustDecimal("100.00").Div(MustDecimal("0.5"), 0), "200"},
+		{"round", MustDecimal("2.345").Round(2), "2.35"},
+		{"round up the scale", MustDecimal("2.3").Round(3), "2.300"},
+		{"neg", MustDecimal("4.2").Neg(), "-4.2"},
+		{"abs", MustDecimal("-4.2").Abs(), "4.2"},
+		{"zero value", Decimal{}.Add(NewDecimal(1999, 2)), "19.99"},
+	}
+	for _, tt := range tests {
+		if tt.got.String() != tt.want {
+			t.Errorf("%s = %s, want %s", tt.name, tt.got, tt.want)
+		}
+	}
+
+	if !MustDecimal("1.50").Equal(MustDecimal("1.5")) || MustDecimal("1.49").Cmp(MustDecimal("1.5")) != -1 {
+		t.Error("Cmp does not ignore the scale")
+	}
+}
+
+func TestDecimalFromFloat(t *testing.T) {
+	tests := []struct {
+		in   float64
+		want string
+		fail bool
+	}{
+		{in: 0.1, want: "0.1"},
+		{in: -2.5, want: "-2.5"},
+		{in: 1e20, want: "100000000000000000000"},
+		{in: math.NaN(), fail: true},
+		{in: math.Inf(1), fail: true},
+		{in: math.Inf(-1), fail: true},
+	}
+	for _, tt := range tests {
+		d, err := DecimalFromFloat(tt.in)
+		if tt.fail != (err != nil) || !tt.fail && d.String() != tt.want {
+			t.Errorf("DecimalFromFloat(%v) = %s, %v", tt.in, d, err)
+		}
+
+		var scanned Decimal
+		if err := scanned.Scan(tt.in); tt.fail != (err != nil) {
+			t.Errorf("Scan(%v) = %v", tt.in, err)
+		}
+	}
+}
+
+func TestNullDecimalJSON(t *testing.T) {
+	tests := []struct {
+		in    string
+		valid bool
+		fail  bool
+	}{
+		{in: `null`},
+		{in: `"1.25"`, valid: true},
+		{in: `3`, valid: true},
+		{in: `"x"`, fail: true},
+		{in: `"1e-5000"`, fail: true},
+	}
+	for _, tt := range tests {
+		var n NullDecimal
+		err := json.Unmarshal([]byte(tt.in), &n)
+		if tt.fail != (err != nil) || n.Valid != tt.valid {
+			t.Errorf("Unmarshal(%s) = %+v, %v", tt.in, n, err)
+		}
+	}
+
+	var n NullDecimal
+	if err := n.Scan("bad"); err == nil || n.Valid {
+		t.Errorf("Scan(bad) = %+v, %v", n, err)
+	}
+}
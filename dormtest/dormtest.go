@@ -0,0 +1,606 @@
+// Package dormtest provides an in-memory dorm.Recorder, to unit test code
+// built on dorm without a database:
+//
+// 	store := dormtest.NewStore()
+//
+// 	u := &User{Email: "bo@example.com"}
+// 	err := store.Bind("users", u).Insert() // sets u.Id
+//
+// 	list, err := dorm.ListWhere(store.Bind("users", &User{}), nil, func(q squirrel.SelectBuilder) squirrel.SelectBuilder {
+// 		return q.Where(squirrel.Gt{"id": 0}).OrderBy("email DESC")
+// 	})
+//
+// Records are kept by primary key, as copies. Load, LoadWhere, Insert,
+// Update, Delete, Exists and ExistsWhere work on them, and so do List,
+// ListWhere, ListByKeys and Count, for conditions built from squirrel Eq,
+// NotEq, Lt, LtOrEq, Gt, GtOrEq, Like, And and Or, dorm Conditions, and plain
+// comparisons with placeholders; with ORDER BY, LIMIT and OFFSET. Conditions
+// follow the three-valued logic of SQL: a comparison with NULL, or its NOT, is
+// unknown and matches no record. Rows come in insertion order unless ordered.
+// Joins, GROUP BY, and the helpers running SQL of their own, such as Pluck or
+// Sum, return an error.
+//
+// The Hooks of the Store run around Insert, Update and Delete, and their ByTx
+// variants, as those of a dorm.DB.
+package dormtest
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/dengsibao/dorm"
+	"github.com/lann/builder"
+)
+
+// Store holds the tables of in-memory Recorders. It is safe for concurrent use.
+type Store struct {
+	// Hooks run around the Insert, Update and Delete of the Recorders of the
+	// Store, as those of a dorm.DB. Set them before using the Store.
+	Hooks dorm.Hooks
+
+	mu     sync.Mutex
+	tables map[string]*table
+}
+
+// table holds the records of a table, by primary key.
+type table struct {
+	rows  map[string]*dorm.DbRecorder
+	order []string // keys in insertion order
+	// last AUTO_INCREMENT value
+	lastID int64
+	// records inserted, which keys the records of tables without a key
+	inserted int
+}
+
+// NewStore returns an empty Store.
+func NewStore() *Store {
+	return &Store{tables: make(map[string]*table)}
+}
+
+// New returns an unbound Recorder on the store, the counterpart of dorm.New.
+func (st *Store) New() *Recorder {
+	return &Recorder{DbRecorder: dorm.New(nil, "sqlite3"), store: st}
+}
+
+// Bind returns a Recorder on the store bound to table and rec.
+func (st *Store) Bind(table string, rec dorm.Record) *Recorder {
+	r := st.New()
+	r.Bind(table, rec)
+	return r
+}
+
+// Len returns the number of records in table.
+func (st *Store) Len(table string) int {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if t := st.tables[table]; t != nil {
+		return len(t.rows)
+	}
+	return 0
+}
+
+// Reset removes every record.
+func (st *Store) Reset() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.tables = make(map[string]*table)
+}
+
+// table returns the table named name, creating it when create is set.
+func (st *Store) table(name string, create bool) *table {
+	t := st.tables[name]
+	if t == nil && create {
+		t = &table{rows: make(map[string]*dorm.DbRecorder)}
+		st.tables[name] = t
+	}
+	return t
+}
+
+// Recorder is a dorm.Recorder keeping its records in a Store instead of a
+// database. The embedded DbRecorder describes the bound Record; its builder
+// has no connection, so whatever would reach the database fails with
+// squirrel.RunnerNotSet.
+type Recorder struct {
+	*dorm.DbRecorder
+	store    *Store
+	affected int64
+	verify   bool
+}
+
+var (
+	_ dorm.Recorder = (*Recorder)(nil)
+	_ dorm.Querier  = (*Recorder)(nil)
+	_ dorm.Cloner   = (*Recorder)(nil)
+)
+
+// Bind binds the Recorder to a table and to a Record, as DbRecorder.Bind.
+func (r *Recorder) Bind(table string, rec dorm.Record) dorm.Recorder {
+	r.DbRecorder.Bind(table, rec)
+	return r
+}
+
+// Clone returns an unbound Recorder on the same store, with the same
+// VerifyAffected setting. It implements dorm.Cloner.
+func (r *Recorder) Clone() dorm.Recorder {
+	c := r.store.New()
+	c.verify = r.verify
+	return c
+}
+
+// VerifyAffected makes Update and Delete return dorm.ErrNoRowsAffected when
+// the primary key matches no record, as DbRecorder.VerifyAffected.
+func (r *Recorder) VerifyAffected(verify bool) *Recorder {
+	r.verify = verify
+	return r
+}
+
+// RowsAffected returns the number of records touched by the last Update or
+// Delete.
+func (r *Recorder) RowsAffected() int64 {
+	return r.affected
+}
+
+// Load loads the bound Record by its primary key. dorm.ErrNotFound is
+// returned when no record matches.
+func (r *Recorder) Load() error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var row *dorm.DbRecorder
+	if t := r.store.table(r.TableName(), false); t != nil {
+		row = t.rows[key(r.DbRecorder)]
+	}
+	if row == nil {
+		return dorm.ErrNotFound
+	}
+	copyFields(r.DbRecorder, row)
+	return nil
+}
+
+// LoadWhere loads the first record matching the WHERE clause, see squirrel's
+// Where. dorm.ErrNotFound is returned when no record matches.
+func (r *Recorder) LoadWhere(pred interface{}, args ...interface{}) error {
+	rows, err := r.query(r.selectAll().Where(pred, args...).Limit(1))
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return dorm.ErrNotFound
+	}
+	copyFields(r.DbRecorder, rows[0])
+	return nil
+}
+
+// Exists reports whether a record has the primary key of the bound Record.
+func (r *Recorder) Exists() (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	t := r.store.table(r.TableName(), false)
+	return t != nil && t.rows[key(r.DbRecorder)] != nil, nil
+}
+
+// ExistsWhere reports whether a record matches the WHERE clause, see
+// squirrel's Where.
+func (r *Recorder) ExistsWhere(pred interface{}, args ...interface{}) (bool, error) {
+	rows, err := r.query(r.selectAll().Where(pred, args...).Limit(1))
+	return len(rows) > 0, err
+}
+
+// Insert stores a copy of the bound Record, after validating it. A zero
+// AUTO_INCREMENT field gets the next id of the table. A primary key already
+// stored fails with a *dorm.ConstraintError matching dorm.ErrDuplicateKey.
+func (r *Recorder) Insert() error {
+	return r.around(r.store.Hooks.BeforeInsert, r.store.Hooks.AfterInsert, r.insert)
+}
+
+func (r *Recorder) insert() error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	t := r.store.table(r.TableName(), true)
+
+	var auto reflect.Value // set when the id is generated
+	if col := r.AutoIncrement(); col != "" {
+		f := r.FieldByColumn(col)
+		id, err := autoID(f)
+		if err != nil {
+			return err
+		}
+		if id == 0 {
+			id, auto = t.lastID+1, f
+			if f.CanInt() {
+				f.SetInt(id)
+			} else {
+				f.SetUint(uint64(id))
+			}
+		}
+		if id > t.lastID {
+			t.lastID = id
+		}
+	}
+
+	k := key(r.DbRecorder)
+	if len(r.Key()) == 0 {
+		k = "#" + strconv.Itoa(t.inserted)
+	}
+	if _, ok := t.rows[k]; ok {
+		if auto.IsValid() {
+			// leave the Record as it was
+			auto.Set(reflect.Zero(auto.Type()))
+		}
+		return &dorm.ConstraintError{
+			Kind:       dorm.ErrDuplicateKey,
+			Constraint: "PRIMARY",
+			Err:        fmt.Errorf("dormtest: %s already has a record with key %s", r.TableName(), k),
+		}
+	}
+
+	t.rows[k] = r.clone()
+	t.order = append(t.order, k)
+	t.inserted++
+	return nil
+}
+
+// InsertByTx is Insert; there are no transactions, so tx is ignored.
+func (r *Recorder) InsertByTx(tx *sql.Tx) error {
+	return r.Insert()
+}
+
+// Update replaces the record with the primary key of the bound Record by a
+// copy of it, after validating it. RowsAffected is 0 when there is none.
+func (r *Recorder) Update() error {
+	return r.around(r.store.Hooks.BeforeUpdate, r.store.Hooks.AfterUpdate, r.update)
+}
+
+func (r *Recorder) update() error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.affected = 0
+	if t := r.store.table(r.TableName(), false); t != nil {
+		if row := t.rows[key(r.DbRecorder)]; row != nil {
+			copyFields(row, r.DbRecorder)
+			r.affected = 1
+		}
+	}
+	return r.verifyAffected()
+}
+
+// UpdateByTx is Update; there are no transactions, so tx is ignored.
+func (r *Recorder) UpdateByTx(tx *sql.Tx) error {
+	return r.Update()
+}
+
+// Delete removes the record with the primary key of the bound Record.
+// RowsAffected is 0 when there is none.
+func (r *Recorder) Delete() error {
+	return r.around(r.store.Hooks.BeforeDelete, r.store.Hooks.AfterDelete, r.delete)
+}
+
+func (r *Recorder) delete() error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.affected = 0
+	if t := r.store.table(r.TableName(), false); t != nil {
+		k := key(r.DbRecorder)
+		if _, ok := t.rows[k]; ok {
+			delete(t.rows, k)
+			for i, o := range t.order {
+				if o == k {
+					t.order = append(t.order[:i], t.order[i+1:]...)
+					break
+				}
+			}
+			r.affected = 1
+		}
+	}
+	return r.verifyAffected()
+}
+
+// DeleteByTx is Delete; there are no transactions, so tx is ignored.
+func (r *Recorder) DeleteByTx(tx *sql.Tx) error {
+	return r.Delete()
+}
+
+// around runs write between the before and after hooks, as dorm.Hooks do
+// around the writes of a DbRecorder: before hooks run ahead of validation and
+// an error from one aborts the write.
+func (r *Recorder) around(before, after []dorm.Hook, write func() error) error {
+	for _, hook := range before {
+		if err := hook(r); err != nil {
+			return err
+		}
+	}
+	if err := write(); err != nil {
+		return err
+	}
+	for _, hook := range after {
+		if err := hook(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Recorder) verifyAffected() error {
+	if r.verify && r.affected == 0 {
+		return dorm.ErrNoRowsAffected
+	}
+	return nil
+}
+
+// QueryList returns copies of the records matching q, bound to new
+// Recorders on the store. It implements dorm.Querier for ListWhere.
+func (r *Recorder) QueryList(q squirrel.SelectBuilder) ([]dorm.Recorder, error) {
+	rows, err := r.query(q)
+	if err != nil {
+		return nil, err
+	}
+
+	var list = make([]dorm.Recorder, len(rows))
+	for i, row := range rows {
+		rec := reflect.New(reflect.Indirect(reflect.ValueOf(r.Interface())).Type())
+		nr := r.Clone().Bind(r.TableName(), rec.Interface()).(*Recorder)
+		copyFields(nr.DbRecorder, row)
+		list[i] = nr
+	}
+	return list, nil
+}
+
+// QueryCount returns the number of records matching q. It implements
+// dorm.Querier for Count.
+func (r *Recorder) QueryCount(q squirrel.SelectBuilder) (int64, error) {
+	rows, err := r.query(q)
+	return int64(len(rows)), err
+}
+
+func (r *Recorder) selectAll() squirrel.SelectBuilder {
+	return r.Builder().Select("*").From(dorm.Quote(r.Driver(), r.TableName()))
+}
+
+// query returns the stored records matching the WHERE, ORDER BY, LIMIT and
+// OFFSET clauses of q. Its columns are ignored.
+func (r *Recorder) query(q squirrel.SelectBuilder) ([]*dorm.DbRecorder, error) {
+	for _, part := range []string{"Joins", "GroupBys", "HavingParts"} {
+		if v, ok := builder.Get(q, part); ok && reflect.ValueOf(v).Len() > 0 {
+			return nil, fmt.Errorf("dormtest: %s are not supported", strings.ToLower(strings.TrimSuffix(part, "Parts")))
+		}
+	}
+
+	var where and
+	if parts, ok := builder.Get(q, "WhereParts"); ok {
+		for _, part := range parts.([]squirrel.Sqlizer) {
+			sql, args, err := part.ToSql()
+			if err != nil {
+				return nil, err
+			}
+			if sql == "" {
+				continue
+			}
+			c, err := parse(sql, args)
+			if err != nil {
+				return nil, err
+			}
+			where = append(where, c)
+		}
+	}
+	var orders []ordering
+	if parts, ok := builder.Get(q, "OrderByParts"); ok {
+		for _, part := range parts.([]squirrel.Sqlizer) {
+			sql, _, err := part.ToSql()
+			if err != nil {
+				return nil, err
+			}
+			o, err := parseOrderBy(sql)
+			if err != nil {
+				return nil, err
+			}
+			orders = append(orders, o...)
+		}
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	t := r.store.table(r.TableName(), false)
+	if t == nil {
+		return nil, nil
+	}
+	var matched []*dorm.DbRecorder
+	var values []row
+	for _, k := range t.order {
+		rec := t.rows[k]
+		vals := rowOf(rec)
+		match, err := where.match(vals)
+		if err != nil {
+			return nil, err
+		}
+		if match == truthy {
+			matched = append(matched, rec)
+			values = append(values, vals)
+		}
+	}
+
+	if len(orders) > 0 {
+		var idx = make([]int, len(matched))
+		for i := range idx {
+			idx[i] = i
+		}
+		var err error
+		sort.SliceStable(idx, func(i, j int) bool {
+			less, e := lessRow(orders, values[idx[i]], values[idx[j]])
+			if e != nil && err == nil {
+				err = e
+			}
+			return less
+		})
+		if err != nil {
+			return nil, err
+		}
+		sorted := make([]*dorm.DbRecorder, len(idx))
+		for i, n := range idx {
+			sorted[i] = matched[n]
+		}
+		matched = sorted
+	}
+
+	offset, limit, err := window(q)
+	if err != nil {
+		return nil, err
+	}
+	if offset > len(matched) {
+		offset = len(matched)
+	}
+	matched = matched[offset:]
+	if limit >= 0 && limit < len(matched) {
+		matched = matched[:limit]
+	}
+	return matched, nil
+}
+
+// window returns the OFFSET and LIMIT of q; -1 is no limit.
+func window(q squirrel.SelectBuilder) (offset, limit int, err error) {
+	limit = -1
+	if v, ok := builder.Get(q, "Limit"); ok && v.(string) != "" {
+		if limit, err = strconv.Atoi(v.(string)); err != nil {
+			return 0, 0, fmt.Errorf("dormtest: bad LIMIT %q", v)
+		}
+	}
+	if v, ok := builder.Get(q, "Offset"); ok && v.(string) != "" {
+		if offset, err = strconv.Atoi(v.(string)); err != nil {
+			return 0, 0, fmt.Errorf("dormtest: bad OFFSET %q", v)
+		}
+	}
+	return offset, limit, nil
+}
+
+// ordering is a term of an ORDER BY clause.
+type ordering struct {
+	column string
+	desc   bool
+}
+
+// parseOrderBy parses "col [ASC|DESC], ...".
+func parseOrderBy(sql string) ([]ordering, error) {
+	var orders []ordering
+	for _, term := range strings.Split(sql, ",") {
+		fields := strings.Fields(term)
+		var o ordering
+		switch {
+		case len(fields) == 1:
+		case len(fields) == 2 && strings.EqualFold(fields[1], "ASC"):
+		case len(fields) == 2 && strings.EqualFold(fields[1], "DESC"):
+			o.desc = true
+		default:
+			return nil, fmt.Errorf("dormtest: cannot order by %q", strings.TrimSpace(term))
+		}
+		o.column = identifier(fields[0])
+		orders = append(orders, o)
+	}
+	return orders, nil
+}
+
+// lessRow orders rows by orders; NULL comes first, as in mysql and sqlite.
+func lessRow(orders []ordering, a, b row) (bool, error) {
+	for _, o := range orders {
+		x, err := column(o.column).value(a)
+		if err != nil {
+			return false, err
+		}
+		y, _ := column(o.column).value(b)
+
+		var n int
+		switch {
+		case x == nil && y == nil:
+		case x == nil:
+			n = -1
+		case y == nil:
+			n = 1
+		default:
+			n, _ = order(x, y)
+		}
+		if o.desc {
+			n = -n
+		}
+		if n != 0 {
+			return n < 0, nil
+		}
+	}
+	return false, nil
+}
+
+// clone returns a DbRecorder bound to a copy of the bound Record.
+func (r *Recorder) clone() *dorm.DbRecorder {
+	rec := reflect.New(reflect.Indirect(reflect.ValueOf(r.Interface())).Type())
+	c := dorm.New(nil, r.Driver())
+	c.Bind(r.TableName(), rec.Interface())
+	copyFields(c, r.DbRecorder)
+	return c
+}
+
+// copyFields copies the mapped fields of src to dst, both bound to the same
+// type. Pointers and slices are copied one level deep, so that records in the
+// store do not share them with the caller.
+func copyFields(dst, src *dorm.DbRecorder) {
+	for _, col := range src.Columns(true) {
+		d, s := dst.FieldByColumn(col), src.FieldByColumn(col)
+		switch {
+		case s.Kind() == reflect.Ptr && !s.IsNil():
+			p := reflect.New(s.Type().Elem())
+			p.Elem().Set(s.Elem())
+			d.Set(p)
+		case s.Kind() == reflect.Slice && !s.IsNil():
+			c := reflect.MakeSlice(s.Type(), s.Len(), s.Len())
+			reflect.Copy(c, s)
+			d.Set(c)
+		default:
+			d.Set(s)
+		}
+	}
+}
+
+// rowOf returns the column values of a record.
+func rowOf(rec *dorm.DbRecorder) row {
+	var r = make(row)
+	for _, col := range rec.Columns(true) {
+		r[col] = normalize(rec.FieldByColumn(col).Interface())
+	}
+	return r
+}
+
+// autoID returns the value of an AUTO_INCREMENT field.
+func autoID(v reflect.Value) (int64, error) {
+	switch {
+	case v.CanInt():
+		return v.Int(), nil
+	case v.CanUint():
+		return int64(v.Uint()), nil
+	}
+	return 0, fmt.Errorf("dormtest: AUTO_INCREMENT field of type %s", v.Type())
+}
+
+// key returns the primary key of the bound Record, as a string: the driver
+// values of its columns, in Key() order.
+func key(rec *dorm.DbRecorder) string {
+	ids := rec.WhereIds()
+	var parts []string
+	for _, col := range rec.Key() {
+		parts = append(parts, fmt.Sprintf("%v", normalize(ids[col])))
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
@@ -0,0 +1,165 @@
+package dormtest
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/dengsibao/dorm"
+)
+
+type user struct {
+	Id   int64          `orm:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Name string         `orm:"name"`
+	Nick sql.NullString `orm:"nick,NULL"`
+	Age  int            `orm:"age"`
+}
+
+// names returns the names of the users of list.
+func names(list []dorm.Recorder) []string {
+	var out []string
+	for _, r := range list {
+		out = append(out, r.Interface().(*user).Name)
+	}
+	return out
+}
+
+func TestStore(t *testing.T) {
+	store := NewStore()
+	for _, u := range []*user{
+		{Name: "bo", Nick: sql.NullString{String: "b", Valid: true}, Age: 30},
+		{Name: "al", Age: 17},
+		{Name: "50%", Nick: sql.NullString{String: "x", Valid: true}, Age: 40},
+	} {
+		if err := store.Bind("users", u).Insert(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Bind("users", &user{Id: 1}).Insert(); !errors.Is(err, dorm.ErrDuplicateKey) {
+		t.Errorf("Insert() of a stored key = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		pred squirrel.Sqlizer
+		want []string
+	}{
+		{"eq", squirrel.Eq{"name": "al"}, []string{"al"}},
+		{"gt", squirrel.Gt{"age": 18}, []string{"bo", "50%"}},
+		{"not eq skips NULL", squirrel.NotEq{"nick": "b"}, []string{"50%"}},
+		{"not in skips NULL", squirrel.NotEq{"nick": []string{"x"}}, []string{"bo"}},
+		{"is null", squirrel.Eq{"nick": nil}, []string{"al"}},
+		{"or", squirrel.Or{squirrel.Eq{"id": 1}, squirrel.Lt{"age": 18}}, []string{"bo", "al"}},
+		{"contains", dorm.Col(store.Bind("users", &user{}), "name").Contains("%"), []string{"50%"}},
+	}
+	for _, tt := range tests {
+		list, err := dorm.ListWhere(store.Bind("users", &user{}), nil, func(q squirrel.SelectBuilder) squirrel.SelectBuilder {
+			return q.Where(tt.pred)
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := names(list); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+		for _, r := range list {
+			if _, ok := r.(*Recorder); !ok {
+				t.Errorf("%s: listed a %T", tt.name, r)
+			}
+		}
+	}
+
+	var u = &user{Id: 2}
+	if err := store.Bind("users", u).Load(); err != nil || u.Name != "al" {
+		t.Fatalf("Load() = %+v, %v", u, err)
+	}
+	u.Age = 18
+	if err := store.Bind("users", u).Update(); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := dorm.Count(store.Bind("users", &user{}), func(q squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return q.Where(squirrel.GtOrEq{"age": 18})
+	}); err != nil || n != 3 {
+		t.Errorf("Count() = %d, %v", n, err)
+	}
+	if err := store.Bind("users", &user{Id: 9}).VerifyAffected(true).Delete(); !errors.Is(err, dorm.ErrNoRowsAffected) {
+		t.Errorf("Delete() of a missing key = %v", err)
+	}
+	if err := store.Bind("users", u).Delete(); err != nil || store.Len("users") != 2 {
+		t.Errorf("Delete() = %v, %d left", err, store.Len("users"))
+	}
+}
+
+func TestStoreHooks(t *testing.T) {
+	var calls []string
+	hook := func(name string, err error) dorm.Hook {
+		return func(d dorm.Recorder) error {
+			if _, ok := d.(*Recorder); !ok {
+				t.Errorf("%s hook got a %T", name, d)
+			}
+			calls = append(calls, name)
+			return err
+		}
+	}
+	abort := errors.New("abort")
+
+	tests := []struct {
+		name  string
+		hooks dorm.Hooks
+		write func(r *Recorder) error
+		calls []string
+		err   error
+		len   int
+	}{
+		{
+			name:  "insert",
+			hooks: dorm.Hooks{BeforeInsert: []dorm.Hook{hook("before", nil)}, AfterInsert: []dorm.Hook{hook("after", nil)}},
+			write: func(r *Recorder) error { return r.Insert() },
+			calls: []string{"before", "after"},
+			len:   2,
+		},
+		{
+			name:  "a before hook aborts",
+			hooks: dorm.Hooks{BeforeInsert: []dorm.Hook{hook("before", abort)}, AfterInsert: []dorm.Hook{hook("after", nil)}},
+			write: func(r *Recorder) error { return r.InsertByTx(nil) },
+			calls: []string{"before"},
+			err:   abort,
+			len:   1,
+		},
+		{
+			name:  "update",
+			hooks: dorm.Hooks{BeforeUpdate: []dorm.Hook{hook("before", nil)}, AfterUpdate: []dorm.Hook{hook("after", abort)}},
+			write: func(r *Recorder) error { r.Interface().(*user).Id = 1; return r.Update() },
+			calls: []string{"before", "after"},
+			err:   abort,
+			len:   1,
+		},
+		{
+			name:  "delete",
+			hooks: dorm.Hooks{BeforeDelete: []dorm.Hook{hook("before", nil)}, AfterDelete: []dorm.Hook{hook("after", nil)}},
+			write: func(r *Recorder) error { r.Interface().(*user).Id = 1; return r.DeleteByTx(nil) },
+			calls: []string{"before", "after"},
+			len:   0,
+		},
+	}
+	for _, tt := range tests {
+		store := NewStore()
+		if err := store.Bind("users", &user{Name: "bo"}).Insert(); err != nil {
+			t.Fatal(err)
+		}
+		store.Hooks, calls = tt.hooks, nil
+
+		err := tt.write(store.Bind("users", &user{Name: "al"}))
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+		}
+		if !reflect.DeepEqual(calls, tt.calls) {
+			t.Errorf("%s: calls = %q, want %q", tt.name, calls, tt.calls)
+		}
+		if store.Len("users") != tt.len {
+			t.Errorf("%s: %d records, want %d", tt.name, store.Len("users"), tt.len)
+		}
+	}
+}
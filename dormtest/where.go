@@ -0,0 +1,652 @@
+package dormtest
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dengsibao/dorm"
+)
+
+// row holds the column values of a record, as driver values.
+type row map[string]interface{}
+
+// cond is a parsed WHERE condition.
+type cond interface {
+	match(r row) (truth, error)
+}
+
+// truth is the result of a condition in the three-valued logic of SQL:
+// comparing NULL to anything is unknown, and so is NOT unknown. Only true
+// matches a record.
+type truth int8
+
+const (
+	falsy truth = iota
+	truthy
+	unknown
+)
+
+func truthOf(b bool) truth {
+	if b {
+		return truthy
+	}
+	return falsy
+}
+
+func (t truth) not() truth {
+	switch t {
+	case truthy:
+		return falsy
+	case falsy:
+		return truthy
+	}
+	return unknown
+}
+
+// operand is a column or a constant of a condition.
+type operand interface {
+	value(r row) (interface{}, error)
+}
+
+type column string
+
+func (c column) value(r row) (interface{}, error) {
+	v, ok := r[string(c)]
+	if !ok {
+		return nil, fmt.Errorf("dormtest: unknown column %q", string(c))
+	}
+	return v, nil
+}
+
+type constant struct {
+	v interface{}
+}
+
+func (c constant) value(row) (interface{}, error) {
+	return c.v, nil
+}
+
+// and is false if any of its conditions is, and otherwise unknown if any is.
+type and []cond
+
+func (a and) match(r row) (truth, error) {
+	var t = truthy
+	for _, c := range a {
+		switch ct, err := c.match(r); {
+		case err != nil || ct == falsy:
+			return falsy, err
+		case ct == unknown:
+			t = unknown
+		}
+	}
+	return t, nil
+}
+
+// or is true if any of its conditions is, and otherwise unknown if any is.
+type or []cond
+
+func (o or) match(r row) (truth, error) {
+	var t = falsy
+	for _, c := range o {
+		switch ct, err := c.match(r); {
+		case err != nil:
+			return falsy, err
+		case ct == truthy:
+			return truthy, nil
+		case ct == unknown:
+			t = unknown
+		}
+	}
+	return t, nil
+}
+
+type not struct {
+	c cond
+}
+
+func (n not) match(r row) (truth, error) {
+	t, err := n.c.match(r)
+	return t.not(), err
+}
+
+// compare is a binary comparison, IN, IS NULL, LIKE or BETWEEN. Comparing
+// NULL is unknown, and so is its negation: x NOT IN (1, NULL) is never true.
+// The operands of LIKE are the pattern and, after ESCAPE, its escape
+// character.
+type compare struct {
+	op  string
+	l   operand
+	r   []operand
+	neg bool
+}
+
+func (c compare) match(r row) (truth, error) {
+	l, err := c.l.value(r)
+	if err != nil {
+		return falsy, err
+	}
+	var rs = make([]interface{}, len(c.r))
+	for i, o := range c.r {
+		if rs[i], err = o.value(r); err != nil {
+			return falsy, err
+		}
+	}
+
+	var t truth
+	switch c.op {
+	case "IS NULL":
+		t = truthOf(l == nil)
+	case "IN":
+		t = falsy
+		for _, v := range rs {
+			if l == nil || v == nil {
+				t = unknown
+			} else if n, comparable := order(l, v); comparable && n == 0 {
+				t = truthy
+				break
+			}
+		}
+	case "LIKE":
+		if l == nil || rs[0] == nil || len(rs) > 1 && rs[1] == nil {
+			return unknown, nil
+		}
+		var escape string
+		if len(rs) > 1 {
+			escape = fmt.Sprint(rs[1])
+		}
+		re, err := likeRegexp(fmt.Sprint(rs[0]), escape)
+		if err != nil {
+			return falsy, err
+		}
+		t = truthOf(re.MatchString(fmt.Sprint(l)))
+	case "BETWEEN":
+		// l >= lo AND l <= hi, so a NULL bound is unknown only if the other holds
+		t, _ = and{
+			compare{op: ">=", l: constant{l}, r: []operand{constant{rs[0]}}},
+			compare{op: "<=", l: constant{l}, r: []operand{constant{rs[1]}}},
+		}.match(r)
+	default:
+		if l == nil || rs[0] == nil {
+			return unknown, nil
+		}
+		n, comparable := order(l, rs[0])
+		if !comparable {
+			return falsy, nil
+		}
+		switch c.op {
+		case "=":
+			t = truthOf(n == 0)
+		case "<>", "!=":
+			t = truthOf(n != 0)
+		case "<":
+			t = truthOf(n < 0)
+		case "<=":
+			t = truthOf(n <= 0)
+		case ">":
+			t = truthOf(n > 0)
+		case ">=":
+			t = truthOf(n >= 0)
+		}
+	}
+	if c.neg {
+		return t.not(), nil
+	}
+	return t, nil
+}
+
+// likeRegexp turns a LIKE pattern into a case insensitive regexp. A character
+// after escape, which is empty or a single character, matches itself.
+func likeRegexp(pattern, escape string) (*regexp.Regexp, error) {
+	if len([]rune(escape)) > 1 {
+		return nil, fmt.Errorf("dormtest: ESCAPE %q is not a single character", escape)
+	}
+	var b strings.Builder
+	b.WriteString("(?is)^")
+	var escaped bool
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+		case escape != "" && string(r) == escape:
+			escaped = true
+		case r == '%':
+			b.WriteString(".*")
+		case r == '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	if escaped {
+		return nil, fmt.Errorf("dormtest: LIKE pattern %q ends with its escape character", pattern)
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String()), nil
+}
+
+// normalize turns a Go value into the driver value a database would store:
+// int64, float64, bool, string, time.Time or nil.
+func normalize(v interface{}) interface{} {
+	dv, err := driver.DefaultParameterConverter.ConvertValue(v)
+	if err != nil {
+		return v
+	}
+	if b, ok := dv.([]byte); ok {
+		return string(b)
+	}
+	return dv
+}
+
+// order compares two normalized values, converting between numbers, strings
+// and times as a database would. It reports false for NULL and for values
+// that cannot be compared.
+func order(a, b interface{}) (int, bool) {
+	if a == nil || b == nil {
+		return 0, false
+	}
+	if x, ok := a.(bool); ok {
+		a = boolInt(x)
+	}
+	if x, ok := b.(bool); ok {
+		b = boolInt(x)
+	}
+
+	switch x := a.(type) {
+	case int64:
+		switch y := b.(type) {
+		case int64:
+			return cmp(x, y), true
+		case float64:
+			return cmp(float64(x), y), true
+		case string:
+			if f, err := strconv.ParseFloat(y, 64); err == nil {
+				return cmp(float64(x), f), true
+			}
+		}
+	case float64:
+		switch y := b.(type) {
+		case int64:
+			return cmp(x, float64(y)), true
+		case float64:
+			return cmp(x, y), true
+		case string:
+			if f, err := strconv.ParseFloat(y, 64); err == nil {
+				return cmp(x, f), true
+			}
+		}
+	case time.Time:
+		switch y := b.(type) {
+		case time.Time:
+			return compareTime(x, y), true
+		case string:
+			if t, err := dorm.ParseTime(y); err == nil && t.Valid {
+				return compareTime(x, t.Time), true
+			}
+		}
+	case string:
+		switch y := b.(type) {
+		case string:
+			return strings.Compare(x, y), true
+		case int64, float64, time.Time:
+			n, ok := order(b, a)
+			return -n, ok
+		}
+	}
+	return 0, false
+}
+
+func compareTime(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	}
+	return 0
+}
+
+func boolInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func cmp[T int64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// parser parses the WHERE conditions that squirrel and dorm build:
+// comparisons, IN, IS NULL, LIKE (with ESCAPE) and BETWEEN on columns, placeholders and
+// literals, combined with AND, OR, NOT and parentheses.
+type parser struct {
+	sql  string
+	toks []string
+	pos  int
+	args []interface{}
+}
+
+func parse(sql string, args []interface{}) (cond, error) {
+	p := &parser{sql: sql, args: args}
+	var err error
+	if p.toks, err = tokenize(sql); err != nil {
+		return nil, err
+	}
+	c, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.toks) {
+		return nil, p.errorf("unexpected %q", p.toks[p.pos])
+	}
+	if len(p.args) > 0 {
+		return nil, p.errorf("%d arguments left over", len(p.args))
+	}
+	return c, nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("dormtest: cannot evaluate %q: %s", p.sql, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos]
+	}
+	return ""
+}
+
+// keyword consumes the given keywords if they come next.
+func (p *parser) keyword(words ...string) bool {
+	for i, w := range words {
+		if p.pos+i >= len(p.toks) || !strings.EqualFold(p.toks[p.pos+i], w) {
+			return false
+		}
+	}
+	p.pos += len(words)
+	return true
+}
+
+func (p *parser) expect(tok string) error {
+	if !p.keyword(tok) {
+		return p.errorf("expected %q", tok)
+	}
+	return nil
+}
+
+func (p *parser) or() (cond, error) {
+	var parts or
+	for {
+		c, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, c)
+		if !p.keyword("OR") {
+			break
+		}
+	}
+	if len(parts) == 1 {
+		return parts[0], nil
+	}
+	return parts, nil
+}
+
+func (p *parser) and() (cond, error) {
+	var parts and
+	for {
+		c, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, c)
+		if !p.keyword("AND") {
+			break
+		}
+	}
+	if len(parts) == 1 {
+		return parts[0], nil
+	}
+	return parts, nil
+}
+
+func (p *parser) unary() (cond, error) {
+	if p.keyword("NOT") {
+		c, err := p.unary()
+		return not{c}, err
+	}
+	if p.peek() == "(" {
+		// a parenthesized condition, or an operand such as (1) = 1
+		save := p.pos
+		p.pos++
+		c, err := p.or()
+		if err == nil && p.keyword(")") {
+			return c, nil
+		}
+		p.pos = save
+	}
+	return p.comparison()
+}
+
+func (p *parser) comparison() (cond, error) {
+	l, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	var neg = p.keyword("NOT")
+	switch {
+	case p.keyword("IS", "NOT", "NULL"):
+		return compare{op: "IS NULL", l: l, neg: true}, nil
+	case p.keyword("IS", "NULL"):
+		return compare{op: "IS NULL", l: l}, nil
+	case p.keyword("IN"):
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		var list []operand
+		for {
+			o, err := p.operand()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, o)
+			if !p.keyword(",") {
+				break
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return compare{op: "IN", l: l, r: list, neg: neg}, nil
+	case p.keyword("LIKE"):
+		r, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		var rs = []operand{r}
+		if p.keyword("ESCAPE") {
+			escape, err := p.operand()
+			if err != nil {
+				return nil, err
+			}
+			rs = append(rs, escape)
+		}
+		return compare{op: "LIKE", l: l, r: rs, neg: neg}, nil
+	case p.keyword("BETWEEN"):
+		lo, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("AND"); err != nil {
+			return nil, err
+		}
+		hi, err := p.operand()
+		return compare{op: "BETWEEN", l: l, r: []operand{lo, hi}, neg: neg}, err
+	case neg:
+		return nil, p.errorf("unexpected NOT")
+	}
+
+	switch op := p.peek(); op {
+	case "=", "<>", "!=", "<", "<=", ">", ">=":
+		p.pos++
+		r, err := p.operand()
+		return compare{op: op, l: l, r: []operand{r}}, err
+	}
+	return nil, p.errorf("expected a comparison, got %q", p.peek())
+}
+
+func (p *parser) operand() (operand, error) {
+	tok := p.peek()
+	p.pos++
+	switch {
+	case tok == "":
+		return nil, p.errorf("unexpected end")
+	case tok == "?":
+		if len(p.args) == 0 {
+			return nil, p.errorf("missing argument")
+		}
+		v := p.args[0]
+		p.args = p.args[1:]
+		return constant{normalize(v)}, nil
+	case tok == "(":
+		o, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		return o, p.expect(")")
+	case tok[0] == '\'':
+		return constant{strings.ReplaceAll(tok[1:len(tok)-1], "''", "'")}, nil
+	case tok[0] >= '0' && tok[0] <= '9' || tok[0] == '-':
+		if n, err := strconv.ParseInt(tok, 10, 64); err == nil {
+			return constant{n}, nil
+		}
+		if f, err := strconv.ParseFloat(tok, 64); err == nil {
+			return constant{f}, nil
+		}
+		return nil, p.errorf("bad number %q", tok)
+	case strings.EqualFold(tok, "NULL"):
+		return constant{nil}, nil
+	case strings.EqualFold(tok, "TRUE"):
+		return constant{int64(1)}, nil
+	case strings.EqualFold(tok, "FALSE"):
+		return constant{int64(0)}, nil
+	case tok[0] == '"' || tok[0] == '`' || isWord(tok):
+		return column(identifier(tok)), nil
+	}
+	return nil, p.errorf("unexpected %q", tok)
+}
+
+// identifier returns the column of a possibly quoted and qualified name.
+func identifier(tok string) string {
+	var parts []string
+	for tok != "" {
+		var part string
+		if q := tok[0]; q == '"' || q == '`' {
+			end := strings.IndexByte(tok[1:], q) + 1
+			part, tok = tok[1:end], tok[end+1:]
+		} else if i := strings.IndexByte(tok, '.'); i >= 0 {
+			part, tok = tok[:i], tok[i:]
+		} else {
+			part, tok = tok, ""
+		}
+		parts = append(parts, part)
+		tok = strings.TrimPrefix(tok, ".")
+	}
+	return parts[len(parts)-1]
+}
+
+func isWord(tok string) bool {
+	c := tok[0]
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+var keywords = map[string]bool{"AND": true, "OR": true, "NOT": true, "IN": true, "IS": true,
+	"NULL": true, "LIKE": true, "ESCAPE": true, "BETWEEN": true, "TRUE": true, "FALSE": true}
+
+// tokenize splits a condition into punctuation, operators, placeholders,
+// string and number literals, keywords and identifiers; quoted and dotted
+// identifiers are one token.
+func tokenize(sql string) ([]string, error) {
+	var toks []string
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.ContainsRune("(),?", rune(c)):
+			toks = append(toks, string(c))
+			i++
+		case strings.HasPrefix(sql[i:], "<>"), strings.HasPrefix(sql[i:], "!="),
+			strings.HasPrefix(sql[i:], "<="), strings.HasPrefix(sql[i:], ">="):
+			toks = append(toks, sql[i:i+2])
+			i += 2
+		case c == '=' || c == '<' || c == '>':
+			toks = append(toks, string(c))
+			i++
+		case c == '\'':
+			j := i + 1
+			for ; j < len(sql); j++ {
+				if sql[j] == '\'' {
+					if j+1 < len(sql) && sql[j+1] == '\'' {
+						j++
+						continue
+					}
+					break
+				}
+			}
+			if j >= len(sql) {
+				return nil, fmt.Errorf("dormtest: unterminated string in %q", sql)
+			}
+			toks = append(toks, sql[i:j+1])
+			i = j + 1
+		case c >= '0' && c <= '9' || c == '-' && i+1 < len(sql) && sql[i+1] >= '0' && sql[i+1] <= '9':
+			j := i + 1
+			for j < len(sql) && (sql[j] >= '0' && sql[j] <= '9' || sql[j] == '.') {
+				j++
+			}
+			toks = append(toks, sql[i:j])
+			i = j
+		case c == '"' || c == '`' || isWord(sql[i:]):
+			j := i
+			for j < len(sql) {
+				if q := sql[j]; q == '"' || q == '`' {
+					end := strings.IndexByte(sql[j+1:], q)
+					if end < 0 {
+						return nil, fmt.Errorf("dormtest: unterminated identifier in %q", sql)
+					}
+					j += end + 2
+				} else {
+					for j < len(sql) && (isWord(sql[j:]) || sql[j] >= '0' && sql[j] <= '9' || sql[j] == '$') {
+						j++
+					}
+				}
+				if j < len(sql) && sql[j] == '.' && j+1 < len(sql) && (sql[j+1] == '"' || sql[j+1] == '`' || isWord(sql[j+1:])) {
+					j++
+					continue
+				}
+				break
+			}
+			tok := sql[i:j]
+			if keywords[strings.ToUpper(tok)] {
+				tok = strings.ToUpper(tok)
+			}
+			toks = append(toks, tok)
+			i = j
+		default:
+			return nil, fmt.Errorf("dormtest: cannot evaluate %q: unexpected %q", sql, string(c))
+		}
+	}
+	return toks, nil
+}
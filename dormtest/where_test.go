@@ -0,0 +1,112 @@
+package dormtest
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want []string
+		fail bool
+	}{
+		{sql: "a = ?", want: []string{"a", "=", "?"}},
+		{sql: "`t`.`a`<>? and b>=-1.5", want: []string{"`t`.`a`", "<>", "?", "AND", "b", ">=", "-1.5"}},
+		{sql: `"users"."name" LIKE 'it''s' ESCAPE '!'`, want: []string{`"users"."name"`, "LIKE", "'it''s'", "ESCAPE", "'!'"}},
+		{sql: "x not in (?,?)", want: []string{"x", "NOT", "IN", "(", "?", ",", "?", ")"}},
+		{sql: "a IS NOT NULL OR b between 1 and 2", want: []string{"a", "IS", "NOT", "NULL", "OR", "b", "BETWEEN", "1", "AND", "2"}},
+		{sql: "a != b\n\tAND c<=d", want: []string{"a", "!=", "b", "AND", "c", "<=", "d"}},
+		{sql: "a = 'open", fail: true},
+		{sql: "`a = 1", fail: true},
+		{sql: "a = @b", fail: true},
+	}
+	for _, tt := range tests {
+		got, err := tokenize(tt.sql)
+		if tt.fail {
+			if err == nil {
+				t.Errorf("tokenize(%q) = %q, want an error", tt.sql, got)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("tokenize(%q) = %q, %v, want %q", tt.sql, got, err, tt.want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	var r = row{
+		"id":   int64(7),
+		"name": "Bo_Smith",
+		"nick": nil,
+		"rate": 1.5,
+		"ok":   true,
+		"at":   time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+	tests := []struct {
+		sql  string
+		args []interface{}
+		want truth
+		fail bool
+	}{
+		{sql: "id = ?", args: []interface{}{7}, want: truthy},
+		{sql: "id = '7'", want: truthy},
+		{sql: "id <> 7", want: falsy},
+		{sql: "rate > 1 AND rate <= 1.5", want: truthy},
+		{sql: "ok = TRUE", want: truthy},
+		{sql: "at >= ?", args: []interface{}{"2024-05-01"}, want: truthy},
+		{sql: "at < ?", args: []interface{}{time.Date(2024, 5, 1, 12, 0, 0, 1, time.UTC)}, want: truthy},
+		{sql: "id IN (1,7)", want: truthy},
+		{sql: "id NOT IN (1,7)", want: falsy},
+		{sql: "id BETWEEN 1 AND 7", want: truthy},
+		{sql: "id NOT BETWEEN 1 AND 7", want: falsy},
+		{sql: "(id = 1 OR id = 7) AND NOT (name = 'x')", want: truthy},
+		{sql: "name LIKE 'bo%'", want: truthy},
+		{sql: "name LIKE 'bo!_s%' ESCAPE '!'", want: truthy},
+		{sql: "name LIKE 'bo!%%' ESCAPE '!'", want: falsy},
+		{sql: "name NOT LIKE '%smith'", want: falsy},
+
+		// NULL is unknown, whether negated or not
+		{sql: "nick IS NULL", want: truthy},
+		{sql: "nick IS NOT NULL", want: falsy},
+		{sql: "nick = ?", args: []interface{}{"b"}, want: unknown},
+		{sql: "NOT (nick = ?)", args: []interface{}{"b"}, want: unknown},
+		{sql: "nick <> 'b'", want: unknown},
+		{sql: "nick IN (?,?)", args: []interface{}{"a", "b"}, want: unknown},
+		{sql: "nick NOT IN (?,?)", args: []interface{}{"a", "b"}, want: unknown},
+		{sql: "id NOT IN (1, NULL)", want: unknown},
+		{sql: "id IN (7, NULL)", want: truthy},
+		{sql: "nick LIKE '%'", want: unknown},
+		{sql: "nick NOT LIKE '%'", want: unknown},
+		{sql: "id BETWEEN NULL AND 5", want: falsy},
+		{sql: "id BETWEEN NULL AND 9", want: unknown},
+		{sql: "nick = 'b' OR id = 7", want: truthy},
+		{sql: "nick = 'b' OR id = 1", want: unknown},
+		{sql: "nick = 'b' AND id = 1", want: falsy},
+		{sql: "NOT (nick = 'b' AND id = 7)", want: unknown},
+
+		{sql: "missing = 1", fail: true},
+		{sql: "id = ?", fail: true},
+		{sql: "id = ? AND", args: []interface{}{1}, fail: true},
+		{sql: "id = 1", args: []interface{}{1}, fail: true},
+		{sql: "name LIKE 'a!' ESCAPE '!'", fail: true},
+	}
+	for _, tt := range tests {
+		c, err := parse(tt.sql, tt.args)
+		var got truth
+		if err == nil {
+			got, err = c.match(r)
+		}
+		if tt.fail {
+			if err == nil {
+				t.Errorf("%s: got %v, want an error", tt.sql, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s = %v, %v, want %v", tt.sql, got, err, tt.want)
+		}
+	}
+}
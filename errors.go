@@ -0,0 +1,223 @@
+package dorm
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// notFoundError is the type behind ErrNotFound. It unwraps to sql.ErrNoRows so
+// that existing `err == sql.ErrNoRows` style checks can move to errors.Is.
+type notFoundError struct{}
+
+func (notFoundError) Error() string { return "dorm: record not found" }
+
+func (notFoundError) Unwrap() error { return sql.ErrNoRows }
+
+var (
+	// ErrNotFound is returned by Load and LoadWhere when no row matches.
+	//
+	// errors.Is(ErrNotFound, sql.ErrNoRows) is true.
+	ErrNotFound error = notFoundError{}
+
+	// ErrDuplicateKey reports a violation of a PRIMARY KEY or UNIQUE constraint.
+	ErrDuplicateKey = errors.New("dorm: duplicate key")
+
+	// ErrForeignKey reports a violation of a FOREIGN KEY constraint.
+	ErrForeignKey = errors.New("dorm: foreign key violation")
+
+	// ErrCheckViolation reports a violation of a CHECK constraint.
+	ErrCheckViolation = errors.New("dorm: check constraint violation")
+
+	// ErrNoRowsAffected is returned by Update and Delete when the primary key
+	// matched no row. See DbRecorder.VerifyAffected.
+	ErrNoRowsAffected = errors.New("dorm: no rows affected")
+
+	// ErrEmptyPredicate is returned by the bulk writes (UpdateWhere, DeleteWhere)
+	// when no WHERE clause was given. Pass AllRows to write every row.
+	ErrEmptyPredicate = errors.New("dorm: refusing to write every row without a predicate, use dorm.AllRows")
+
+	// ErrValidation is matched by every *ValidationError.
+	ErrValidation = errors.New("dorm: validation failed")
+
+	// ErrUnknownColumn reports a column name that the bound Record does not have.
+	ErrUnknownColumn = errors.New("dorm: unknown column")
+
+	// ErrSchemaDrift is matched by a *SchemaReport listing differences between
+	// Records and their live tables.
+	ErrSchemaDrift = errors.New("dorm: schema drift")
+)
+
+// ConstraintError is returned when a statement violates a database constraint.
+//
+// Kind is one of ErrDuplicateKey, ErrForeignKey or ErrCheckViolation, so the
+// error can be matched with errors.Is. Use errors.As to get at the name of the
+// constraint (or column, depending on what the driver reports):
+//
+//	var ce *dorm.ConstraintError
+//	if errors.As(err, &ce) && errors.Is(err, dorm.ErrDuplicateKey) {
+//		log.Printf("duplicate on %s", ce.Constraint)
+//	}
+type ConstraintError struct {
+	Kind       error
+	Constraint string
+	Err        error
+}
+
+func (e *ConstraintError) Error() string {
+	if e.Constraint == "" {
+		return fmt.Sprintf("%v: %v", e.Kind, e.Err)
+	}
+	return fmt.Sprintf("%v (%s): %v", e.Kind, e.Constraint, e.Err)
+}
+
+// Is reports whether target is the kind of this constraint error.
+func (e *ConstraintError) Is(target error) bool {
+	return target == e.Kind
+}
+
+// Unwrap returns the original driver error.
+func (e *ConstraintError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	mysqlCodeRe       = regexp.MustCompile(`Error (\d{4})`)
+	mysqlDupKeyRe     = regexp.MustCompile(`for key '([^']+)'`)
+	mysqlConstraintRe = regexp.MustCompile("(?i)constraint [`']([^`']+)[`']")
+	pgCodeRe          = regexp.MustCompile(`SQLSTATE (\w{5})`)
+	pgConstraintRe    = regexp.MustCompile(`constraint "([^"]+)"`)
+	sqliteRe          = regexp.MustCompile(`(UNIQUE|PRIMARY KEY|FOREIGN KEY|CHECK) constraint failed(?:: ([^(]+))?`)
+)
+
+// TranslateError converts driver specific errors into the errors of this package.
+//
+// sql.ErrNoRows becomes ErrNotFound, and constraint violations reported by the
+// mysql, postgres and sqlite drivers become a *ConstraintError. Any other error
+// is returned untouched. The flavor is the same string given to New.
+//
+// The driver packages are not imported; errors are recognised by their exported
+// fields (MySQLError.Number, pq.Error.Code, pgconn.PgError.Code) or their message.
+func TranslateError(flavor string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if err == ErrNotFound {
+		return err
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	var ce *ConstraintError
+	if errors.As(err, &ce) {
+		return err
+	}
+
+	switch flavor {
+	case "postgres":
+		return translatePg(err)
+	case "sqlite3", "sqlite":
+		return translateSqlite(err)
+	default:
+		return translateMysql(err)
+	}
+}
+
+func translateMysql(err error) error {
+	var code int64
+	if v, ok := errorField(err, "Number"); ok && v.Kind() >= reflect.Uint && v.Kind() <= reflect.Uint64 {
+		code = int64(v.Uint())
+	} else if m := mysqlCodeRe.FindStringSubmatch(err.Error()); m != nil {
+		code, _ = strconv.ParseInt(m[1], 10, 64)
+	}
+
+	var kind error
+	var re = mysqlConstraintRe
+	switch code {
+	case 1062, 1586:
+		kind, re = ErrDuplicateKey, mysqlDupKeyRe
+	case 1216, 1217, 1451, 1452:
+		kind = ErrForeignKey
+	case 3819:
+		kind = ErrCheckViolation
+	default:
+		return err
+	}
+
+	var name string
+	if m := re.FindStringSubmatch(err.Error()); m != nil {
+		name = m[1]
+	}
+	return &ConstraintError{Kind: kind, Constraint: name, Err: err}
+}
+
+func translatePg(err error) error {
+	var code string
+	if v, ok := errorField(err, "Code"); ok && v.Kind() == reflect.String {
+		code = v.String()
+	} else if m := pgCodeRe.FindStringSubmatch(err.Error()); m != nil {
+		code = m[1]
+	}
+
+	var kind error
+	switch code {
+	case "23505":
+		kind = ErrDuplicateKey
+	case "23503":
+		kind = ErrForeignKey
+	case "23514":
+		kind = ErrCheckViolation
+	default:
+		return err
+	}
+
+	var name string
+	if v, ok := errorField(err, "Constraint", "ConstraintName"); ok && v.Kind() == reflect.String {
+		name = v.String()
+	}
+	if name == "" {
+		if m := pgConstraintRe.FindStringSubmatch(err.Error()); m != nil {
+			name = m[1]
+		}
+	}
+	return &ConstraintError{Kind: kind, Constraint: name, Err: err}
+}
+
+func translateSqlite(err error) error {
+	m := sqliteRe.FindStringSubmatch(err.Error())
+	if m == nil {
+		return err
+	}
+
+	var kind error
+	switch m[1] {
+	case "UNIQUE", "PRIMARY KEY":
+		kind = ErrDuplicateKey
+	case "FOREIGN KEY":
+		kind = ErrForeignKey
+	case "CHECK":
+		kind = ErrCheckViolation
+	}
+	return &ConstraintError{Kind: kind, Constraint: strings.TrimSpace(m[2]), Err: err}
+}
+
+// errorField walks the error chain and returns the first exported struct field
+// found under one of the given names.
+func errorField(err error, names ...string) (reflect.Value, bool) {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		v := reflect.Indirect(reflect.ValueOf(e))
+		if v.Kind() != reflect.Struct {
+			continue
+		}
+		for _, name := range names {
+			if f := v.FieldByName(name); f.IsValid() && f.CanInterface() {
+				return f, true
+			}
+		}
+	}
+	return reflect.Value{}, false
+}
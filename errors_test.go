@@ -0,0 +1,115 @@
+package dorm
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// mysqlError has the shape of mysql.MySQLError.
+type mysqlError struct {
+	Number  uint16
+	Message string
+}
+
+func (e *mysqlError) Error() string { return fmt.Sprintf("Error %d: %s", e.Number, e.Message) }
+
+// pqErrorCode and pqError have the shape of pq.ErrorCode and pq.Error.
+type pqErrorCode string
+
+type pqError struct {
+	Code       pqErrorCode
+	Message    string
+	Constraint string
+}
+
+func (e *pqError) Error() string { return "pq: " + e.Message }
+
+// pgError has the shape of pgconn.PgError.
+type pgError struct {
+	Code           string
+	Message        string
+	ConstraintName string
+}
+
+func (e *pgError) Error() string { return e.Message + " (SQLSTATE " + e.Code + ")" }
+
+func TestTranslateError(t *testing.T) {
+	other := errors.New("connection refused")
+	tests := []struct {
+		name       string
+		flavor     string
+		err        error
+		kind       error
+		constraint string
+	}{
+		{name: "nil", flavor: "mysql", err: nil, kind: nil},
+		{name: "no rows", flavor: "mysql", err: sql.ErrNoRows, kind: ErrNotFound},
+		{name: "wrapped no rows", flavor: "postgres", err: fmt.Errorf("load: %w", sql.ErrNoRows), kind: ErrNotFound},
+		{name: "other", flavor: "mysql", err: other, kind: other},
+
+		{name: "mysql duplicate", flavor: "mysql", err: &mysqlError{1062, "Duplicate entry 'bo' for key 'users.email'"},
+			kind: ErrDuplicateKey, constraint: "users.email"},
+		{name: "mysql duplicate from the message", flavor: "mysql",
+			err:  errors.New("Error 1062 (23000): Duplicate entry 'bo' for key 'email'"),
+			kind: ErrDuplicateKey, constraint: "email"},
+		{name: "mysql foreign key", flavor: "mysql",
+			err:  &mysqlError{1452, "Cannot add or update a child row: a foreign key constraint fails (`shop`.`orders`, CONSTRAINT `fk_user` FOREIGN KEY (`user_id`))"},
+			kind: ErrForeignKey, constraint: "fk_user"},
+		{name: "mysql check", flavor: "mysql", err: &mysqlError{3819, "Check constraint 'age_positive' is violated."},
+			kind: ErrCheckViolation, constraint: "age_positive"},
+		{name: "mysql other code", flavor: "mysql", err: &mysqlError{1146, "Table 'shop.nope' doesn't exist"}},
+
+		{name: "pq duplicate", flavor: "postgres", err: &pqError{"23505", "duplicate key value", "users_email_key"},
+			kind: ErrDuplicateKey, constraint: "users_email_key"},
+		{name: "pq foreign key from the message", flavor: "postgres",
+			err:  &pqError{Code: "23503", Message: `insert or update on table "orders" violates foreign key constraint "orders_user_id_fkey"`},
+			kind: ErrForeignKey, constraint: "orders_user_id_fkey"},
+		{name: "pgx check", flavor: "postgres", err: &pgError{"23514", "violates check constraint", "age_positive"},
+			kind: ErrCheckViolation, constraint: "age_positive"},
+		{name: "pg code from the message", flavor: "postgres",
+			err:  errors.New(`ERROR: duplicate key value violates unique constraint "users_pkey" (SQLSTATE 23505)`),
+			kind: ErrDuplicateKey, constraint: "users_pkey"},
+		{name: "pg other code", flavor: "postgres", err: &pgError{Code: "42P01", Message: "relation does not exist"}},
+
+		{name: "sqlite unique", flavor: "sqlite3", err: errors.New("UNIQUE constraint failed: users.email"),
+			kind: ErrDuplicateKey, constraint: "users.email"},
+		{name: "sqlite composite unique", flavor: "sqlite3", err: errors.New("UNIQUE constraint failed: members.team_id, members.user_id"),
+			kind: ErrDuplicateKey, constraint: "members.team_id, members.user_id"},
+		{name: "sqlite primary key", flavor: "sqlite", err: errors.New("PRIMARY KEY constraint failed: users.id (1555)"),
+			kind: ErrDuplicateKey, constraint: "users.id"},
+		{name: "sqlite foreign key", flavor: "sqlite3", err: errors.New("FOREIGN KEY constraint failed"),
+			kind: ErrForeignKey},
+		{name: "sqlite check", flavor: "sqlite3", err: errors.New("CHECK constraint failed: age_positive"),
+			kind: ErrCheckViolation, constraint: "age_positive"},
+		{name: "sqlite other", flavor: "sqlite3", err: errors.New("no such table: nope")},
+	}
+	for _, tt := range tests {
+		got := TranslateError(tt.flavor, tt.err)
+		if tt.kind == nil {
+			if got != tt.err {
+				t.Errorf("%s: TranslateError() = %v, want it untouched", tt.name, got)
+			}
+			continue
+		}
+		if !errors.Is(got, tt.kind) {
+			t.Errorf("%s: TranslateError() = %v, want %v", tt.name, got, tt.kind)
+			continue
+		}
+
+		var ce *ConstraintError
+		if errors.As(got, &ce) {
+			if ce.Constraint != tt.constraint {
+				t.Errorf("%s: constraint = %q, want %q", tt.name, ce.Constraint, tt.constraint)
+			}
+			if !errors.Is(got, tt.err) || TranslateError(tt.flavor, got) != got {
+				t.Errorf("%s: %v does not wrap the driver error once", tt.name, got)
+			}
+		}
+	}
+
+	if !errors.Is(ErrNotFound, sql.ErrNoRows) {
+		t.Error("ErrNotFound does not match sql.ErrNoRows")
+	}
+}
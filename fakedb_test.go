@@ -0,0 +1,140 @@
+package dorm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// fakeDB is a database/sql connector for tests that need no real database. It
+// records the statements it is given and answers queries from a queue of rows.
+type fakeDB struct {
+	db *sql.DB
+
+	mu    sync.Mutex
+	stmts []string
+	args  [][]driver.Value
+	// answers to the next queries, in order; a query past them gets no rows
+	rows []*fakeRows
+	// rows affected by, and id inserted by, every Exec
+	affected, lastID int64
+	// returned by the next statement, if set
+	err error
+}
+
+// fakeRows is the answer to a query.
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+// openFake returns a fakeDB and a DbRecorder of the given flavor on it. Execs
+// report one row affected.
+func openFake(t *testing.T, flavor string) (*fakeDB, *DbRecorder) {
+	t.Helper()
+	f := &fakeDB{affected: 1}
+	f.db = sql.OpenDB(f)
+	t.Cleanup(func() { f.db.Close() })
+	return f, New(squirrel.NewStmtCacheProxy(f.db), flavor)
+}
+
+// answer queues the rows of the next query.
+func (f *fakeDB) answer(columns []string, values ...[]driver.Value) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rows = append(f.rows, &fakeRows{columns: columns, values: values})
+}
+
+// last returns the last statement run and its arguments.
+func (f *fakeDB) last() (string, []driver.Value) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.stmts) == 0 {
+		return "", nil
+	}
+	return f.stmts[len(f.stmts)-1], f.args[len(f.args)-1]
+}
+
+// run records a statement and returns the error to fail it with, if any.
+func (f *fakeDB) run(query string, args []driver.Value) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stmts = append(f.stmts, query)
+	f.args = append(f.args, args)
+	err := f.err
+	f.err = nil
+	return err
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{f}, nil }
+
+func (f *fakeDB) Driver() driver.Driver { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("fakeDB: use sql.OpenDB")
+}
+
+type fakeConn struct{ f *fakeDB }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.f, query}, nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	f     *fakeDB
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if err := s.f.run(s.query, args); err != nil {
+		return nil, err
+	}
+	return fakeResult{s.f.lastID, s.f.affected}, nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if err := s.f.run(s.query, args); err != nil {
+		return nil, err
+	}
+	s.f.mu.Lock()
+	defer s.f.mu.Unlock()
+	if len(s.f.rows) == 0 {
+		return &fakeRows{}, nil
+	}
+	r := s.f.rows[0]
+	s.f.rows = s.f.rows[1:]
+	return r, nil
+}
+
+type fakeResult struct{ id, affected int64 }
+
+func (r fakeResult) LastInsertId() (int64, error) { return r.id, nil }
+func (r fakeResult) RowsAffected() (int64, error) { return r.affected, nil }
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
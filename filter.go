@@ -0,0 +1,171 @@
+package dorm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// TagFilter 'filter' maps a field of a filter struct to a condition, see FilterFrom.
+const TagFilter = "filter"
+
+// FilterFrom turns a filter struct, typically decoded from the query of a list
+// API, into a Condition on the columns of d. Each field tagged
+// `filter:"column,op"` adds a condition, and they are all ANDed:
+//
+// 	type UserFilter struct {
+// 		Status        string    `json:"status" filter:"status,eq"`
+// 		Name          string    `json:"name" filter:"name,like"`
+// 		Ids           []int64   `json:"ids" filter:"id,in"`
+// 		CreatedAtFrom dorm.Time `json:"createdAtFrom" filter:"created_at,gte"`
+// 		Deleted       *bool     `json:"deleted" filter:"deleted"`
+// 	}
+//
+// 	list, err := dorm.ListWhere(d, nil, dorm.FilterFrom(d, &f).Apply)
+//
+// The operators are eq (the default), ne, gt, gte, lt, lte, like, pattern, in,
+// notin and null, which takes a bool: IS NULL when true, IS NOT NULL when
+// false. A like value matches anywhere in the column and is taken literally,
+// as Column.Contains; pattern passes the value as a LIKE pattern, wildcards
+// included, so keep it to trusted input. Fields holding a zero value, a nil
+// pointer or an empty slice are skipped, so use pointers to filter on zero
+// values. An empty column is named from the field name.
+//
+// Fields without a filter tag but with an 'orm' tag compare for equality with
+// their column, so a Record can serve as an example to query by. Other fields
+// are ignored, and so are fields tagged filter:"-". Embedded structs are
+// descended into.
+//
+// Columns are checked against d, and a bad tag is reported too: the error
+// comes out of the Condition's ToSql, and Err.
+func FilterFrom(d Recorder, filter interface{}) *Condition {
+	var cond = Where(d)
+	v := reflect.Indirect(reflect.ValueOf(filter))
+	if v.Kind() != reflect.Struct {
+		cond.err = fmt.Errorf("dorm: filter must be a struct, got %T", filter)
+		return cond
+	}
+
+	var conds []*Condition
+	var walk func(v reflect.Value)
+	walk = func(v reflect.Value) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag, tagged := f.Tag.Lookup(TagFilter)
+			if tag == "-" || f.PkgPath != "" && !f.Anonymous {
+				continue
+			}
+
+			fv := v.Field(i)
+			if f.Anonymous && !tagged {
+				if fv.Kind() == reflect.Ptr && !fv.IsNil() {
+					fv = fv.Elem()
+				}
+				if fv.Kind() == reflect.Struct && !isColumnType(fv.Type()) {
+					walk(fv)
+					continue
+				}
+			}
+			if f.PkgPath != "" {
+				continue
+			}
+
+			var column, op string
+			switch orm := strings.TrimSpace(strings.Split(f.Tag.Get(TagOrm), ",")[0]); {
+			case tagged:
+				column, op, _ = strings.Cut(tag, ",")
+			case orm != "" && orm != "-" && orm != "embedded":
+				column = orm
+			default:
+				continue
+			}
+			column, op = strings.TrimSpace(column), strings.ToLower(strings.TrimSpace(op))
+			if column == "" {
+				column = namingOf(d).ColumnName(f.Name)
+			}
+
+			// the column and operator are checked whether or not the field is set
+			col := Col(d, column)
+			if col.err != nil {
+				conds = append(conds, &Condition{d: d, err: col.err})
+				continue
+			}
+			if c := filterCond(col, op, fv); c != nil {
+				conds = append(conds, c)
+			}
+		}
+	}
+	walk(v)
+	return cond.And(conds...)
+}
+
+// filterCond returns the condition of a filter field, or nil if its value is
+// to be skipped.
+func filterCond(col *Column, op string, v reflect.Value) *Condition {
+	switch op {
+	case "", "eq", "ne", "gt", "gte", "lt", "lte", "like", "pattern", "in", "notin", "null":
+	default:
+		return &Condition{d: col.d, err: fmt.Errorf("dorm: filter %s: unknown operator %q", col.name, op)}
+	}
+
+	var set = false
+	if v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v, set = v.Elem(), true
+	}
+	if v.IsZero() && !set || (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Len() == 0 {
+		return nil
+	}
+	var x = v.Interface()
+
+	switch op {
+	case "", "eq":
+		return col.Eq(x)
+	case "ne":
+		return col.NotEq(x)
+	case "gt":
+		return col.Gt(x)
+	case "gte":
+		return col.Gte(x)
+	case "lt":
+		return col.Lt(x)
+	case "lte":
+		return col.Lte(x)
+	case "like", "pattern":
+		if v.Kind() != reflect.String {
+			return &Condition{d: col.d, err: fmt.Errorf("dorm: filter %s: %s needs a string, got %T", col.name, op, x)}
+		}
+		if op == "pattern" {
+			return col.Like(v.String())
+		}
+		return col.Contains(v.String())
+	case "in", "notin":
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return &Condition{d: col.d, err: fmt.Errorf("dorm: filter %s: %s needs a slice, got %T", col.name, op, x)}
+		}
+		if op == "in" {
+			return col.In(x)
+		}
+		return col.NotIn(x)
+	case "null":
+		if v.Kind() != reflect.Bool {
+			return &Condition{d: col.d, err: fmt.Errorf("dorm: filter %s: null needs a bool, got %T", col.name, x)}
+		}
+		if v.Bool() {
+			return col.IsNull()
+		}
+		return col.IsNotNull()
+	}
+	return nil
+}
+
+// namingOf returns the NamingStrategy of d.
+func namingOf(d Recorder) NamingStrategy {
+	if s, ok := d.(*DbRecorder); ok {
+		return s.namingStrategy()
+	}
+	return Naming
+}
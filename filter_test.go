@@ -0,0 +1,24 @@
+package dorm
+
+import (
+	"testing"
+)
+
+func TestContainsSql(t *testing.T) {
+	tests := []struct {
+		flavor, sql string
+	}{
+		{"mysql", "`name` LIKE ? ESCAPE '!'"},
+		{"postgres", `"name" LIKE ? ESCAPE '!'`},
+	}
+	for _, tt := range tests {
+		d := New(nil, tt.flavor).Bind("records", &account{})
+		sql, args, err := Where(d).Col("name").Contains("5%_!").ToSql()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sql != tt.sql || len(args) != 1 || args[0] != "%5!%!_!!%" {
+			t.Errorf("%s: %s %v", tt.flavor, sql, args)
+		}
+	}
+}
@@ -0,0 +1,479 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/dengsibao/dorm"
+)
+
+const dormPath = "github.com/dengsibao/dorm"
+
+// modelFields are the fields of dorm.Model with their columns.
+var modelFields = [][2]string{
+	{"Id", "id"},
+	{"CreatedAt", "created_at"},
+	{"UpdatedAt", "updated_at"},
+	{"Deleted", "deleted"},
+}
+
+// column is a column found on a struct, with the selector path to its field.
+type column struct {
+	name string
+	path []string
+	// label names the constant: the field, after the named embedded fields
+	// on its path
+	label string
+	ptr   bool
+	depth int
+	// embedded pointers on the path, which may be nil
+	guards []string
+}
+
+// model is a struct to generate accessors for.
+type model struct {
+	typeName string
+	table    string
+	columns  []column
+	// embedded pointers to allocate before taking field addresses, outermost first
+	allocs [][2]string
+}
+
+// Columns parses the Go package in dir and returns a gofmt'ed file holding,
+// for each of the given struct types (or every struct with 'orm' tags when
+// types is empty):
+//
+//   - a <Type>Table constant with the table name, taken from a `dorm:table name`
+//     line in the type's doc comment or from a TableName method returning a
+//     string literal;
+//   - a <Type>Col<Field> constant per column, named after the fields of
+//     `orm:"embedded"` structs too (OrderColBillingCity);
+//   - DormColumns, DormFieldRefs and DormValues methods implementing
+//     dorm.FieldAccessor, which DbRecorder uses instead of reflection.
+//
+// Files whose name is skip are ignored, so that the output of a previous run
+// does not interfere.
+func Columns(dir string, types []string, skip string) ([]byte, error) {
+	fset := token.NewFileSet()
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	var pkg string
+	var files []*ast.File
+	for _, p := range paths {
+		base := filepath.Base(p)
+		if base == skip || strings.HasSuffix(base, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, p, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		pkg = f.Name.Name
+		files = append(files, f)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("gen: no Go files in %s", dir)
+	}
+
+	p := newParsedPackage(files)
+
+	var names = types
+	if len(names) == 0 {
+		names = p.taggedStructs()
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("gen: no struct with %q tags in %s", "orm", dir)
+	}
+
+	var models []*model
+	for _, name := range names {
+		m, err := p.model(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		models = append(models, m)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by dormgen columns. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	fmt.Fprintf(&buf, "import %q\n\n", dormPath)
+	for _, m := range models {
+		writeAccessors(&buf, m)
+	}
+
+	return formatSource(buf.Bytes())
+}
+
+// WriteColumns runs Columns and writes the result to dir/out.
+func WriteColumns(dir string, types []string, out string) error {
+	src, err := Columns(dir, types, out)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, out), src, 0644)
+}
+
+type structDecl struct {
+	doc  *ast.CommentGroup
+	st   *ast.StructType
+	file *ast.File
+}
+
+type parsedPackage struct {
+	structs map[string]*structDecl
+	order   []string
+	// table names returned by TableName methods, by receiver type
+	tableNames map[string]string
+}
+
+func newParsedPackage(files []*ast.File) *parsedPackage {
+	p := &parsedPackage{structs: make(map[string]*structDecl), tableNames: make(map[string]string)}
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			switch d := decl.(type) {
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					ts, ok := spec.(*ast.TypeSpec)
+					if !ok {
+						continue
+					}
+					st, ok := ts.Type.(*ast.StructType)
+					if !ok {
+						continue
+					}
+					doc := ts.Doc
+					if doc == nil {
+						doc = d.Doc
+					}
+					p.structs[ts.Name.Name] = &structDecl{doc: doc, st: st, file: f}
+					p.order = append(p.order, ts.Name.Name)
+				}
+			case *ast.FuncDecl:
+				if name, table, ok := tableNameMethod(d); ok {
+					p.tableNames[name] = table
+				}
+			}
+		}
+	}
+	return p
+}
+
+// tableNameMethod recognises `func (T) TableName() string { return "name" }`.
+func tableNameMethod(d *ast.FuncDecl) (recv, table string, ok bool) {
+	if d.Recv == nil || len(d.Recv.List) != 1 || d.Name.Name != "TableName" || d.Body == nil || len(d.Body.List) != 1 {
+		return "", "", false
+	}
+	ret, isRet := d.Body.List[0].(*ast.ReturnStmt)
+	if !isRet || len(ret.Results) != 1 {
+		return "", "", false
+	}
+	lit, isLit := ret.Results[0].(*ast.BasicLit)
+	if !isLit || lit.Kind != token.STRING {
+		return "", "", false
+	}
+	table, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return "", "", false
+	}
+
+	t := d.Recv.List[0].Type
+	if star, isStar := t.(*ast.StarExpr); isStar {
+		t = star.X
+	}
+	id, isIdent := t.(*ast.Ident)
+	if !isIdent {
+		return "", "", false
+	}
+	return id.Name, table, true
+}
+
+// taggedStructs returns the structs having at least one field with an 'orm'
+// tag, or embedding dorm.Model.
+func (p *parsedPackage) taggedStructs() []string {
+	var names []string
+	for _, name := range p.order {
+		d := p.structs[name]
+		for _, f := range d.st.Fields.List {
+			if _, ok := lookupTag(f, "orm"); ok || isDormModel(f.Type, d.file) {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	return names
+}
+
+func (p *parsedPackage) model(name string) (*model, error) {
+	d, ok := p.structs[name]
+	if !ok {
+		return nil, fmt.Errorf("gen: struct type %s not found", name)
+	}
+
+	m := &model{typeName: name, table: p.tableNames[name]}
+	if d.doc != nil {
+		for _, c := range d.doc.List {
+			text := strings.TrimSpace(strings.TrimLeft(c.Text, "/*"))
+			if strings.HasPrefix(text, "dorm:table ") {
+				m.table = strings.TrimSpace(strings.TrimPrefix(text, "dorm:table "))
+			}
+		}
+	}
+
+	if err := p.walk(m, d, nil, nil, "", "", 0, map[string]bool{name: true}); err != nil {
+		return nil, err
+	}
+
+	// Like Go field promotion, a shallower field hides a deeper one.
+	var kept = make(map[string]int)
+	var cols []column
+	for _, c := range m.columns {
+		if i, ok := kept[c.name]; ok {
+			if c.depth < cols[i].depth {
+				cols[i] = c
+			}
+			continue
+		}
+		kept[c.name] = len(cols)
+		cols = append(cols, c)
+	}
+	m.columns = cols
+	return m, nil
+}
+
+func (p *parsedPackage) walk(m *model, d *structDecl, path, guards []string, prefix, label string, depth int, seen map[string]bool) error {
+	for _, f := range d.st.Fields.List {
+		tag, _ := lookupTag(f, "orm")
+		parts := strings.Split(tag, ",")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		if parts[0] == "-" {
+			continue
+		}
+
+		if len(f.Names) == 0 {
+			if err := p.embed(m, d, f, "", parts, path, guards, prefix, label, depth, seen); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, n := range f.Names {
+			if !n.IsExported() {
+				continue
+			}
+			if parts[0] == "embedded" {
+				if err := p.embed(m, d, f, n.Name, parts, path, guards, prefix, label, depth, seen); err != nil {
+					return err
+				}
+				continue
+			}
+			col := parts[0]
+			if col == "" {
+				col = columnName(n.Name)
+			}
+			_, ptr := f.Type.(*ast.StarExpr)
+			m.columns = append(m.columns, column{name: prefix + col, path: appendPath(path, n.Name), label: label + n.Name, ptr: ptr, depth: depth, guards: guards})
+		}
+	}
+	return nil
+}
+
+// embed handles an anonymous field, or a named one tagged embedded: dorm.Model,
+// or a struct of this package.
+func (p *parsedPackage) embed(m *model, d *structDecl, f *ast.Field, name string, parts, path, guards []string, prefix, label string, depth int, seen map[string]bool) error {
+	if name != "" {
+		label += name
+	}
+	t := f.Type
+	_, ptr := t.(*ast.StarExpr)
+	if ptr {
+		t = t.(*ast.StarExpr).X
+	}
+	for _, part := range parts[1:] {
+		if strings.HasPrefix(part, "prefix:") {
+			prefix += strings.TrimPrefix(part, "prefix:")
+		}
+	}
+
+	if isDormModel(t, d.file) {
+		if name == "" {
+			name = "Model"
+		}
+		if ptr {
+			at := strings.Join(appendPath(path, name), ".")
+			m.allocs = append(m.allocs, [2]string{at, "dorm.Model"})
+			guards = appendPath(guards, at)
+		}
+		for _, mf := range modelFields {
+			m.columns = append(m.columns, column{name: prefix + mf[1], path: appendPath(path, name, mf[0]), label: label + mf[0], depth: depth + 1, guards: guards})
+		}
+		return nil
+	}
+
+	id, ok := t.(*ast.Ident)
+	if !ok {
+		return fmt.Errorf("gen: %s: cannot resolve embedded field %s", m.typeName, exprString(f.Type))
+	}
+	if name == "" {
+		name = id.Name
+	}
+	sub, ok := p.structs[id.Name]
+	if !ok {
+		// An embedded non-struct type is an ordinary column.
+		m.columns = append(m.columns, column{name: prefix + columnName(name), path: appendPath(path, name), label: label + name, ptr: ptr, depth: depth, guards: guards})
+		return nil
+	}
+	if seen[id.Name] {
+		return fmt.Errorf("gen: %s: recursive embedding of %s", m.typeName, id.Name)
+	}
+	seen[id.Name] = true
+	defer delete(seen, id.Name)
+
+	if ptr {
+		at := strings.Join(appendPath(path, name), ".")
+		m.allocs = append(m.allocs, [2]string{at, id.Name})
+		guards = appendPath(guards, at)
+	}
+	return p.walk(m, sub, appendPath(path, name), guards, prefix, label, depth+1, seen)
+}
+
+func isDormModel(t ast.Expr, f *ast.File) bool {
+	if star, ok := t.(*ast.StarExpr); ok {
+		t = star.X
+	}
+	sel, ok := t.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Model" {
+		return false
+	}
+	x, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	for _, imp := range f.Imports {
+		if path, _ := strconv.Unquote(imp.Path.Value); path == dormPath {
+			name := "dorm"
+			if imp.Name != nil {
+				name = imp.Name.Name
+			}
+			return name == x.Name
+		}
+	}
+	return false
+}
+
+func lookupTag(f *ast.Field, key string) (string, bool) {
+	if f.Tag == nil {
+		return "", false
+	}
+	raw, err := strconv.Unquote(f.Tag.Value)
+	if err != nil {
+		return "", false
+	}
+	return reflect.StructTag(raw).Lookup(key)
+}
+
+func appendPath(path []string, names ...string) []string {
+	return append(append([]string{}, path...), names...)
+}
+
+func writeAccessors(w *bytes.Buffer, m *model) {
+	t := m.typeName
+
+	w.WriteString("const (\n")
+	if m.table != "" {
+		fmt.Fprintf(w, "\t// %sTable is the table of %s.\n\t%sTable = %q\n", t, t, t, m.table)
+	}
+	var consts = make([]string, len(m.columns))
+	var used = make(map[string]int)
+	for i, c := range m.columns {
+		name := t + "Col" + c.label
+		if n := used[name]; n > 0 {
+			name += strconv.Itoa(n + 1)
+		}
+		used[name]++
+		consts[i] = name
+		fmt.Fprintf(w, "\t%s = %q\n", name, c.name)
+	}
+	w.WriteString(")\n\n")
+
+	fmt.Fprintf(w, "var _ dorm.FieldAccessor = (*%s)(nil)\n\n", t)
+
+	fmt.Fprintf(w, "// DormColumns implements dorm.FieldAccessor.\nfunc (r *%s) DormColumns() []string {\n", t)
+	fmt.Fprintf(w, "\treturn []string{%s}\n}\n\n", strings.Join(consts, ", "))
+
+	fmt.Fprintf(w, "// DormFieldRefs implements dorm.FieldAccessor.\nfunc (r *%s) DormFieldRefs() []interface{} {\n", t)
+	for _, a := range m.allocs {
+		fmt.Fprintf(w, "\tif r.%s == nil {\n\t\tr.%s = new(%s)\n\t}\n", a[0], a[0], a[1])
+	}
+	w.WriteString("\treturn []interface{}{\n")
+	for _, c := range m.columns {
+		fmt.Fprintf(w, "\t\t&r.%s,\n", strings.Join(c.path, "."))
+	}
+	w.WriteString("\t}\n}\n\n")
+
+	fmt.Fprintf(w, "// DormValues implements dorm.FieldAccessor.\nfunc (r *%s) DormValues() []interface{} {\n", t)
+	var vals = make([]string, len(m.columns))
+	for i, c := range m.columns {
+		field := "r." + strings.Join(c.path, ".")
+		var conds []string
+		for _, g := range c.guards {
+			conds = append(conds, "r."+g+" != nil")
+		}
+		if c.ptr {
+			conds = append(conds, field+" != nil")
+		}
+		if len(conds) > 0 {
+			v := fmt.Sprintf("v%d", i)
+			fmt.Fprintf(w, "\tvar %s interface{}\n\tif %s {\n\t\t%s = %s\n\t}\n", v, strings.Join(conds, " && "), v, field)
+			field = v
+		}
+		vals[i] = field
+	}
+	w.WriteString("\treturn []interface{}{\n")
+	for _, v := range vals {
+		fmt.Fprintf(w, "\t\t%s,\n", v)
+	}
+	w.WriteString("\t}\n}\n\n")
+}
+
+// Naming names the columns of fields without a name in their 'orm' tag, and
+// must be the NamingStrategy the structs are bound with: otherwise the
+// generated columns do not match and DbRecorder keeps using reflection. When
+// nil, dorm.Naming is used, so a generator main for a custom strategy can set
+// either before calling Columns:
+//
+// 	gen.Naming = shop.Naming{}
+// 	err := gen.WriteColumns("models", nil, "dorm_columns_gen.go")
+var Naming dorm.NamingStrategy
+
+// columnName mirrors the column naming of dorm for fields without an 'orm' tag.
+func columnName(name string) string {
+	if Naming != nil {
+		return Naming.ColumnName(name)
+	}
+	return dorm.Naming.ColumnName(name)
+}
+
+func exprString(e ast.Expr) string {
+	switch t := e.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return "*" + exprString(t.X)
+	case *ast.SelectorExpr:
+		return exprString(t.X) + "." + t.Sel.Name
+	}
+	return fmt.Sprintf("%T", e)
+}
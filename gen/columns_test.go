@@ -0,0 +1,155 @@
+package gen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dengsibao/dorm"
+)
+
+const modelsSrc = `package models
+
+import "github.com/dengsibao/dorm"
+
+// Audit is embedded by pointer.
+type Audit struct {
+	CreatedBy string ` + "`orm:\"created_by\"`" + `
+}
+
+// Order is a model.
+//
+// dorm:table shop_orders
+type Order struct {
+	dorm.Model
+	*Audit
+	CustomerId int64
+	Note       *string ` + "`orm:\"note,NULL\"`" + `
+	Skipped    string  ` + "`orm:\"-\"`" + `
+	internal   string
+}
+
+type Customer struct {
+	Id   int64  ` + "`orm:\"id,PRIMARY_KEY\"`" + `
+	Name string ` + "`orm:\"name\"`" + `
+}
+
+func (Customer) TableName() string { return "customers" }
+
+type plain struct {
+	A int
+}
+`
+
+func TestColumns(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "models.go"), []byte(modelsSrc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// A stale output file must not be parsed.
+	if err := os.WriteFile(filepath.Join(dir, "dorm_columns.go"), []byte("package models\nbroken"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		types   []string
+		want    []string
+		without []string
+		err     bool
+	}{
+		{
+			name:  "tagged structs",
+			types: nil,
+			want: []string{
+				"// Code generated by dormgen columns. DO NOT EDIT.",
+				`OrderTable = "shop_orders"`,
+				`OrderColId = "id"`,
+				`OrderColCreatedBy = "created_by"`,
+				`OrderColCustomerId = "customer_id"`,
+				`CustomerTable = "customers"`,
+				"func (r *Order) DormFieldRefs() []interface{} {\n\tif r.Audit == nil {\n\t\tr.Audit = new(Audit)\n\t}",
+				"&r.Model.Deleted,\n\t\t&r.Audit.CreatedBy,",
+				"if r.Audit != nil {\n\t\tv4 = r.Audit.CreatedBy\n\t}",
+				"if r.Note != nil {",
+			},
+			without: []string{"Skipped", "internal", "plain", "DeletedAt"},
+		},
+		{
+			name:    "selected type",
+			types:   []string{" Customer"},
+			want:    []string{"var _ dorm.FieldAccessor = (*Customer)(nil)", "return []string{CustomerColId, CustomerColName}"},
+			without: []string{"Order"},
+		},
+		{name: "unknown type", types: []string{"Nope"}, err: true},
+	}
+	for _, tt := range tests {
+		src, err := Columns(dir, tt.types, "dorm_columns.go")
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s: Columns() succeeded", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		for _, w := range tt.want {
+			if !containsCode(src, w) {
+				t.Errorf("%s: missing %q in\n%s", tt.name, w, src)
+			}
+		}
+		for _, w := range tt.without {
+			if strings.Contains(string(src), w) {
+				t.Errorf("%s: unexpected %q in\n%s", tt.name, w, src)
+			}
+		}
+	}
+
+	if _, err := Columns(t.TempDir(), nil, ""); err == nil {
+		t.Error("Columns() of an empty directory succeeded")
+	}
+}
+
+// containsCode reports whether src contains code, ignoring the amount of white
+// space gofmt uses for alignment.
+func containsCode(src []byte, code string) bool {
+	return strings.Contains(strings.Join(strings.Fields(string(src)), " "), strings.Join(strings.Fields(code), " "))
+}
+
+// keepNaming names columns after their Go fields.
+type keepNaming struct{}
+
+func (keepNaming) TableName(typeName string) string   { return typeName }
+func (keepNaming) ColumnName(fieldName string) string { return fieldName }
+
+func TestColumnsNaming(t *testing.T) {
+	dir := t.TempDir()
+	src := "package models\n\ntype Widget struct {\n\tUserID int64 `orm:\",PRIMARY_KEY\"`\n}\n"
+	if err := os.WriteFile(filepath.Join(dir, "models.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		naming dorm.NamingStrategy
+		global dorm.NamingStrategy
+		want   string
+	}{
+		{want: `WidgetColUserID = "user_id"`},
+		{global: keepNaming{}, want: `WidgetColUserID = "UserID"`},
+		{naming: keepNaming{}, want: `WidgetColUserID = "UserID"`},
+	}
+	defer func(n dorm.NamingStrategy) { dorm.Naming, Naming = n, nil }(dorm.Naming)
+	for _, tt := range tests {
+		Naming, dorm.Naming = tt.naming, dorm.SnakeNaming{}
+		if tt.global != nil {
+			dorm.Naming = tt.global
+		}
+		out, err := Columns(dir, nil, "")
+		if err != nil || !containsCode(out, tt.want) {
+			t.Errorf("Naming %T, dorm.Naming %T: Columns() = %v\n%s", tt.naming, tt.global, err, out)
+		}
+	}
+}
@@ -0,0 +1,344 @@
+// Package gen generates Go source for dorm models.
+//
+// It backs the dormgen command, and can be used directly from other tools.
+package gen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/dengsibao/dorm"
+)
+
+// Table is a live table to generate a struct for.
+type Table struct {
+	// Name of the table, optionally schema qualified.
+	Name string
+	// Columns as returned by dorm.InspectColumns.
+	Columns []dorm.ColumnInfo
+	// Samples holds a non-NULL value of json columns, by column name. It is used
+	// to pick dorm.Strings or dorm.Int64s over json.RawMessage.
+	Samples map[string][]byte
+}
+
+// modelColumns are the columns provided by dorm.Model. A deleted_at column gets
+// a field of its own, since Model does not map it.
+var modelColumns = []string{"id", "created_at", "updated_at", "deleted"}
+
+// Structs returns a gofmt'ed Go file in package pkg holding one struct per table.
+//
+// Tables that have every column of dorm.Model, with the types Model gives
+// them, embed it; others get plain fields. Datetime, date and time
+// columns use dorm.Time, dorm.Date and dorm.TimeOfDay, decimal columns use
+// dorm.Decimal with precision and scale tags; json columns holding string or
+// integer arrays use dorm.Strings and dorm.Int64s. Column comments go to the
+// 'desc' tag, and types that the Go type would not reproduce in GetSchema are
+// kept in 'columnDefinition'.
+//
+// Type names are the singular of the table names; two tables naming the same
+// type, such as user and users, are an error.
+func Structs(pkg string, tables []Table) ([]byte, error) {
+	var body bytes.Buffer
+	var imports = make(map[string]bool)
+	var types = make(map[string]string)
+
+	for _, t := range tables {
+		typeName := typeName(t)
+		if other, ok := types[typeName]; ok {
+			return nil, fmt.Errorf("gen: tables %s and %s both map to type %s", other, t.Name, typeName)
+		}
+		types[typeName] = t.Name
+		writeStruct(&body, t, typeName, imports)
+	}
+
+	var buf bytes.Buffer
+	// The structs are a starting point to be edited, so no DO NOT EDIT marker.
+	fmt.Fprintf(&buf, "// Generated by dormgen from the database schema.\n\npackage %s\n\n", pkg)
+	writeImports(&buf, imports)
+	buf.Write(body.Bytes())
+
+	return formatSource(buf.Bytes())
+}
+
+func formatSource(src []byte) ([]byte, error) {
+	out, err := format.Source(src)
+	if err != nil {
+		return src, fmt.Errorf("gen: formatting generated source: %v", err)
+	}
+	return out, nil
+}
+
+// writeImports writes an import block, standard library first.
+func writeImports(w *bytes.Buffer, imports map[string]bool) {
+	if len(imports) == 0 {
+		return
+	}
+	var std, other []string
+	for p := range imports {
+		if strings.Contains(strings.Split(p, "/")[0], ".") {
+			other = append(other, p)
+		} else {
+			std = append(std, p)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+
+	w.WriteString("import (\n")
+	for _, p := range std {
+		fmt.Fprintf(w, "\t%q\n", p)
+	}
+	if len(std) > 0 && len(other) > 0 {
+		w.WriteString("\n")
+	}
+	for _, p := range other {
+		fmt.Fprintf(w, "\t%q\n", p)
+	}
+	w.WriteString(")\n\n")
+}
+
+// typeName returns the Go type of the struct of t.
+func typeName(t Table) string {
+	var name = t.Name
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return GoName(Singular(name))
+}
+
+func writeStruct(w *bytes.Buffer, t Table, typeName string, imports map[string]bool) {
+	var skip = make(map[string]bool)
+	var model = fitsModel(t.Columns)
+	if model {
+		for _, c := range modelColumns {
+			skip[c] = true
+		}
+		imports["github.com/dengsibao/dorm"] = true
+	}
+
+	fmt.Fprintf(w, "// %s maps the %s table.\n", typeName, t.Name)
+	fmt.Fprintf(w, "type %s struct {\n", typeName)
+	if model {
+		w.WriteString("\tdorm.Model\n")
+	}
+	for _, c := range t.Columns {
+		if skip[c.Name] {
+			continue
+		}
+		typ, def, imp := goType(c, t.Samples[c.Name])
+		if imp != "" {
+			imports[imp] = true
+		}
+		fmt.Fprintf(w, "\t%s %s %s\n", GoName(c.Name), typ, structTag(c, def))
+	}
+	w.WriteString("}\n\n")
+}
+
+// fitsModel reports whether the model columns are all there with the types
+// dorm.Model gives them: id an integer auto increment primary key, the only
+// one, created_at and updated_at datetimes, and deleted a NOT NULL boolean.
+func fitsModel(cols []dorm.ColumnInfo) bool {
+	var fits = make(map[string]bool, len(modelColumns))
+	for _, c := range cols {
+		typ, _, _ := goType(c, nil)
+		switch c.Name {
+		case "id":
+			fits[c.Name] = c.PrimaryKey && c.AutoIncrement && (typ == "int" || typ == "int64")
+		case "created_at", "updated_at":
+			fits[c.Name] = typ == "dorm.Time"
+		case "deleted":
+			fits[c.Name] = typ == "bool"
+		default:
+			if c.PrimaryKey {
+				return false
+			}
+		}
+	}
+	for _, n := range modelColumns {
+		if !fits[n] {
+			return false
+		}
+	}
+	return true
+}
+
+// goType picks the Go type of a column. def is the column definition to keep
+// in the struct tag when the type alone would not reproduce it, imp the import
+// path the type needs.
+func goType(c dorm.ColumnInfo, sample []byte) (typ, def, imp string) {
+	nullable := func(t, null string) (string, string, string) {
+		if c.Nullable {
+			return null, "", "database/sql"
+		}
+		return t, "", ""
+	}
+
+	switch c.DataType {
+	case "bool", "boolean":
+		return nullable("bool", "sql.NullBool")
+	case "tinyint":
+		if c.ColumnType == "tinyint(1)" {
+			return nullable("bool", "sql.NullBool")
+		}
+		return nullable("int", "sql.NullInt64")
+	case "smallint", "mediumint", "int", "integer", "serial", "smallserial":
+		return nullable("int", "sql.NullInt64")
+	case "bigint", "bigserial":
+		return nullable("int64", "sql.NullInt64")
+	case "float", "double", "real", "double precision":
+		return nullable("float64", "sql.NullFloat64")
+	case "decimal", "numeric":
+		if c.Nullable {
+			return "dorm.NullDecimal", "", "github.com/dengsibao/dorm"
+		}
+		return "dorm.Decimal", "", "github.com/dengsibao/dorm"
+	case "date":
+		return "dorm.Date", "", "github.com/dengsibao/dorm"
+	case "time":
+		return "dorm.TimeOfDay", "", "github.com/dengsibao/dorm"
+	case "datetime", "timestamp", "timestamptz":
+		return "dorm.Time", "", "github.com/dengsibao/dorm"
+	case "json", "jsonb":
+		switch jsonArray(sample) {
+		case "string":
+			return "dorm.Strings", "", "github.com/dengsibao/dorm"
+		case "integer":
+			return "dorm.Int64s", "", "github.com/dengsibao/dorm"
+		}
+		return "json.RawMessage", c.ColumnType, "encoding/json"
+	case "blob", "tinyblob", "mediumblob", "longblob", "binary", "varbinary", "bytea":
+		return "[]byte", c.ColumnType, ""
+	case "varchar", "character varying":
+		typ, _, imp = nullable("string", "sql.NullString")
+		return typ, "", imp
+	default:
+		typ, _, imp = nullable("string", "sql.NullString")
+		return typ, c.ColumnType, imp
+	}
+}
+
+// jsonArray reports the element kind of a json array sample: "string",
+// "integer" or "" for anything else.
+func jsonArray(sample []byte) string {
+	if len(sample) == 0 {
+		return ""
+	}
+	var strs []string
+	if json.Unmarshal(sample, &strs) == nil {
+		return "string"
+	}
+	var ints []int64
+	if json.Unmarshal(sample, &ints) == nil {
+		return "integer"
+	}
+	return ""
+}
+
+func structTag(c dorm.ColumnInfo, def string) string {
+	var orm = []string{c.Name}
+	if c.PrimaryKey {
+		orm = append(orm, "PRIMARY_KEY")
+	}
+	if c.AutoIncrement {
+		orm = append(orm, "AUTO_INCREMENT")
+	}
+	if c.Unique {
+		orm = append(orm, "UNIQUE")
+	}
+	if c.Nullable {
+		orm = append(orm, "NULL")
+	}
+
+	var parts = []string{
+		tagPart(dorm.TagOrm, strings.Join(orm, ",")),
+		tagPart("json", lowerFirst(GoName(c.Name))),
+	}
+	if def != "" {
+		parts = append(parts, tagPart(dorm.TagColumnDefinition, def))
+	} else if c.Length > 0 && (c.DataType == "varchar" || c.DataType == "character varying") {
+		parts = append(parts, tagPart(dorm.TagLength, strconv.FormatInt(c.Length, 10)))
+	} else if c.Precision > 0 && (c.DataType == "decimal" || c.DataType == "numeric") {
+		parts = append(parts, tagPart(dorm.TagPrecision, strconv.FormatInt(c.Precision, 10)),
+			tagPart(dorm.TagScale, strconv.FormatInt(c.Scale, 10)))
+	}
+	if c.Comment != "" {
+		parts = append(parts, tagPart(dorm.TagComment, c.Comment))
+	}
+
+	var tag = strings.Join(parts, " ")
+	if strings.Contains(tag, "`") {
+		return strconv.Quote(tag)
+	}
+	return "`" + tag + "`"
+}
+
+func tagPart(key, value string) string {
+	return key + ":" + strconv.Quote(value)
+}
+
+// GoName turns a snake_case database name into an exported Go identifier,
+// in the style of dorm.Model: user_id becomes UserId.
+func GoName(name string) string {
+	var b strings.Builder
+	var upper = true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+
+	var s = b.String()
+	if s == "" || unicode.IsDigit(rune(s[0])) {
+		s = "F" + s
+	}
+	return s
+}
+
+// uncountable are words ending in s that are not plurals.
+var uncountable = []string{"news", "series", "species", "status", "analysis", "basis", "axis", "sms", "gps"}
+
+// Singular makes a plural English table name singular: users becomes user,
+// categories becomes category. The last word of a snake case name is the one
+// changed, and words such as news or series are left alone.
+func Singular(name string) string {
+	var last = strings.ToLower(name[strings.LastIndex(name, "_")+1:])
+	for _, w := range uncountable {
+		if last == w {
+			return name
+		}
+	}
+
+	switch {
+	case strings.HasSuffix(name, "ies") && len(name) > 3:
+		return name[:len(name)-3] + "y"
+	case strings.HasSuffix(name, "sses"), strings.HasSuffix(name, "xes"),
+		strings.HasSuffix(name, "ches"), strings.HasSuffix(name, "shes"):
+		return name[:len(name)-2]
+	case strings.HasSuffix(name, "ss"), strings.HasSuffix(name, "us"):
+		return name
+	case strings.HasSuffix(name, "s") && len(name) > 1:
+		return name[:len(name)-1]
+	}
+	return name
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
@@ -0,0 +1,200 @@
+package gen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dengsibao/dorm"
+)
+
+var modelInfo = []dorm.ColumnInfo{
+	{Name: "id", DataType: "bigint", ColumnType: "bigint", PrimaryKey: true, AutoIncrement: true},
+	{Name: "created_at", DataType: "datetime", ColumnType: "datetime"},
+	{Name: "updated_at", DataType: "datetime", ColumnType: "datetime"},
+	{Name: "deleted", DataType: "tinyint", ColumnType: "tinyint(1)"},
+	{Name: "deleted_at", DataType: "datetime", ColumnType: "datetime", Nullable: true},
+}
+
+func TestStructs(t *testing.T) {
+	tests := []struct {
+		name    string
+		table   Table
+		want    []string
+		without []string
+	}{
+		{
+			name: "model",
+			table: Table{Name: "shop.categories", Columns: append(modelInfo[:len(modelInfo):len(modelInfo)],
+				dorm.ColumnInfo{Name: "title", DataType: "varchar", ColumnType: "varchar(64)", Length: 64, Comment: "shown `as is`"})},
+			want: []string{
+				"// Category maps the shop.categories table.",
+				"\tdorm.Model\n",
+				"Title string \"orm:\\\"title\\\" json:\\\"title\\\" length:\\\"64\\\" desc:\\\"shown `as is`\\\"\"",
+				"\"github.com/dengsibao/dorm\"",
+			},
+			without: []string{"CreatedAt"},
+		},
+		{
+			name: "plain",
+			table: Table{Name: "users", Columns: []dorm.ColumnInfo{
+				{Name: "user_id", DataType: "int", ColumnType: "int(11)", PrimaryKey: true},
+				{Name: "nick", DataType: "varchar", ColumnType: "varchar(32)", Nullable: true, Unique: true, Length: 32},
+				{Name: "bio", DataType: "text", ColumnType: "text"},
+				{Name: "tags", DataType: "json", ColumnType: "json"},
+				{Name: "extra", DataType: "json", ColumnType: "json"},
+				{Name: "avatar", DataType: "blob", ColumnType: "blob"},
+				{Name: "seen_at", DataType: "timestamp", ColumnType: "timestamp"},
+				{Name: "born_on", DataType: "date", ColumnType: "date"},
+				{Name: "opens_at", DataType: "time", ColumnType: "time", Nullable: true},
+			}, Samples: map[string][]byte{"tags": []byte(`["a"]`)}},
+			want: []string{
+				"type User struct",
+				"UserId int `orm:\"user_id,PRIMARY_KEY\" json:\"userId\"`",
+				"Nick   sql.NullString `orm:\"nick,UNIQUE,NULL\" json:\"nick\" length:\"32\"`",
+				"Bio    string `orm:\"bio\" json:\"bio\" columnDefinition:\"text\"`",
+				"Tags   dorm.Strings",
+				"Extra  json.RawMessage `orm:\"extra\" json:\"extra\" columnDefinition:\"json\"`",
+				"Avatar []byte `orm:\"avatar\" json:\"avatar\" columnDefinition:\"blob\"`",
+				"SeenAt dorm.Time",
+				"BornOn dorm.Date `orm:\"born_on\" json:\"bornOn\"`",
+				"OpensAt dorm.TimeOfDay `orm:\"opens_at,NULL\" json:\"opensAt\"`",
+				"import (\n\t\"database/sql\"\n\t\"encoding/json\"\n\n\t\"github.com/dengsibao/dorm\"\n)",
+			},
+			without: []string{"dorm.Model"},
+		},
+	}
+	for _, tt := range tests {
+		src, err := Structs("models", []Table{tt.table})
+		if err != nil {
+			t.Errorf("%s: %v\n%s", tt.name, err, src)
+			continue
+		}
+		for _, w := range tt.want {
+			if !containsCode(src, w) {
+				t.Errorf("%s: missing %q in\n%s", tt.name, w, src)
+			}
+		}
+		for _, w := range tt.without {
+			if strings.Contains(string(src), w) {
+				t.Errorf("%s: unexpected %q in\n%s", tt.name, w, src)
+			}
+		}
+	}
+}
+
+func TestStructsModelTypes(t *testing.T) {
+	// with returns modelInfo with column c replacing the one of the same name,
+	// or added.
+	with := func(c dorm.ColumnInfo) []dorm.ColumnInfo {
+		var cols = append([]dorm.ColumnInfo(nil), modelInfo...)
+		for i := range cols {
+			if cols[i].Name == c.Name {
+				cols[i] = c
+				return cols
+			}
+		}
+		return append(cols, c)
+	}
+	tests := []struct {
+		name  string
+		cols  []dorm.ColumnInfo
+		model bool
+		field string
+	}{
+		{name: "model columns", cols: modelInfo, model: true},
+		{name: "postgres types", model: true, cols: []dorm.ColumnInfo{
+			{Name: "id", DataType: "bigint", ColumnType: "bigint", PrimaryKey: true, AutoIncrement: true},
+			{Name: "created_at", DataType: "timestamptz", ColumnType: "timestamp with time zone"},
+			{Name: "updated_at", DataType: "timestamp", ColumnType: "timestamp without time zone"},
+			{Name: "deleted", DataType: "boolean", ColumnType: "boolean"},
+		}},
+		{
+			name:  "string id",
+			cols:  with(dorm.ColumnInfo{Name: "id", DataType: "varchar", ColumnType: "varchar(36)", PrimaryKey: true}),
+			field: "Id string `orm:\"id,PRIMARY_KEY\" json:\"id\"`",
+		},
+		{
+			name:  "id without auto increment",
+			cols:  with(dorm.ColumnInfo{Name: "id", DataType: "bigint", ColumnType: "bigint", PrimaryKey: true}),
+			field: "Id int64 `orm:\"id,PRIMARY_KEY\" json:\"id\"`",
+		},
+		{
+			name:  "id not the primary key",
+			cols:  with(dorm.ColumnInfo{Name: "id", DataType: "bigint", ColumnType: "bigint", AutoIncrement: true}),
+			field: "Id int64 `orm:\"id,AUTO_INCREMENT\" json:\"id\"`",
+		},
+		{
+			name:  "composite key",
+			cols:  with(dorm.ColumnInfo{Name: "tenant_id", DataType: "bigint", ColumnType: "bigint", PrimaryKey: true}),
+			field: "TenantId int64 `orm:\"tenant_id,PRIMARY_KEY\" json:\"tenantId\"`",
+		},
+		{
+			name:  "unix time created_at",
+			cols:  with(dorm.ColumnInfo{Name: "created_at", DataType: "int", ColumnType: "int"}),
+			field: "CreatedAt int `orm:\"created_at\" json:\"createdAt\"`",
+		},
+		{
+			name:  "varchar updated_at",
+			cols:  with(dorm.ColumnInfo{Name: "updated_at", DataType: "varchar", ColumnType: "varchar(32)", Length: 32}),
+			field: "UpdatedAt string `orm:\"updated_at\" json:\"updatedAt\" length:\"32\"`",
+		},
+		{
+			name:  "integer deleted",
+			cols:  with(dorm.ColumnInfo{Name: "deleted", DataType: "int", ColumnType: "int"}),
+			field: "Deleted int `orm:\"deleted\" json:\"deleted\"`",
+		},
+		{
+			name:  "nullable deleted",
+			cols:  with(dorm.ColumnInfo{Name: "deleted", DataType: "boolean", ColumnType: "boolean", Nullable: true}),
+			field: "Deleted sql.NullBool `orm:\"deleted,NULL\" json:\"deleted\"`",
+		},
+	}
+	for _, tt := range tests {
+		src, err := Structs("models", []Table{{Name: "items", Columns: tt.cols}})
+		if err != nil {
+			t.Errorf("%s: %v\n%s", tt.name, err, src)
+			continue
+		}
+		if got := strings.Contains(string(src), "dorm.Model"); got != tt.model {
+			t.Errorf("%s: embeds dorm.Model %v, want %v\n%s", tt.name, got, tt.model, src)
+		}
+		if tt.field != "" && !containsCode(src, tt.field) {
+			t.Errorf("%s: missing %q in\n%s", tt.name, tt.field, src)
+		}
+	}
+}
+
+func TestStructsRejectsCollidingTypes(t *testing.T) {
+	cols := []dorm.ColumnInfo{{Name: "id", DataType: "int", ColumnType: "int"}}
+	_, err := Structs("models", []Table{{Name: "user", Columns: cols}, {Name: "shop.users", Columns: cols}})
+	if err == nil || !strings.Contains(err.Error(), "user and shop.users") {
+		t.Errorf("Structs() = %v, want a collision error", err)
+	}
+}
+
+func TestNames(t *testing.T) {
+	tests := []struct {
+		in, goName, singular string
+	}{
+		{"users", "Users", "user"},
+		{"categories", "Categories", "category"},
+		{"addresses", "Addresses", "address"},
+		{"boxes", "Boxes", "box"},
+		{"status", "Status", "status"},
+		{"user_id", "UserId", "user_id"},
+		{"2fa_codes", "F2faCodes", "2fa_code"},
+		{"--", "F", "--"},
+		{"news", "News", "news"},
+		{"tv_series", "TvSeries", "tv_series"},
+		{"order_status", "OrderStatus", "order_status"},
+		{"sms_logs", "SmsLogs", "sms_log"},
+	}
+	for _, tt := range tests {
+		if got := GoName(tt.in); got != tt.goName {
+			t.Errorf("GoName(%q) = %q, want %q", tt.in, got, tt.goName)
+		}
+		if got := Singular(tt.in); got != tt.singular {
+			t.Errorf("Singular(%q) = %q, want %q", tt.in, got, tt.singular)
+		}
+	}
+}
@@ -1,11 +1,14 @@
 module github.com/dengsibao/dorm
 
-go 1.16
+go 1.18
 
 require (
 	github.com/Masterminds/squirrel v1.5.2
-	github.com/sirupsen/logrus v1.8.1
+	github.com/lann/builder v0.0.0-20180802200727-47ae307949d0
+)
+
+require (
+	github.com/lann/ps v0.0.0-20150810152359-62de8c46ede0 // indirect
 	github.com/stretchr/testify v1.7.0 // indirect
-	golang.org/x/sys v0.0.0-20211103235746-7861aae1554b // indirect
 	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
 )
@@ -0,0 +1,271 @@
+package dorm
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// ColumnInfo describes a column of a live table, as read from the database
+// catalog by InspectColumns.
+type ColumnInfo struct {
+	// Name is the column name.
+	Name string
+	// DataType is the lower cased base type, e.g. varchar, bigint, timestamptz.
+	// Postgres arrays are reported as the element type followed by [].
+	DataType string
+	// ColumnType is the full type, e.g. varchar(255), decimal(12,4), tinyint(1).
+	ColumnType string
+	// Nullable reports whether the column accepts NULL.
+	Nullable bool
+	// Default is the default expression, if any.
+	Default sql.NullString
+	// Comment is the column comment (not supported by sqlite).
+	Comment string
+	// PrimaryKey reports whether the column is part of the primary key.
+	PrimaryKey bool
+	// AutoIncrement reports AUTO_INCREMENT, serial/identity or sqlite rowid columns.
+	AutoIncrement bool
+	// Unique reports whether a single column unique index covers the column.
+	Unique bool
+	// Length is the maximum character length, 0 if not applicable.
+	Length int64
+	// Precision and Scale of numeric types, 0 if not applicable.
+	Precision, Scale int64
+}
+
+// InspectTables lists the base tables of the current database (mysql), schema
+// (postgres) or file (sqlite).
+func InspectTables(db squirrel.Queryer, flavor string) ([]string, error) {
+	var q string
+	switch flavor {
+	case "postgres":
+		q = `SELECT table_name FROM information_schema.tables
+			WHERE table_schema = current_schema() AND table_type = 'BASE TABLE' ORDER BY table_name`
+	case "sqlite3", "sqlite":
+		q = `SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`
+	default:
+		q = `SELECT TABLE_NAME FROM information_schema.TABLES
+			WHERE TABLE_SCHEMA = DATABASE() AND TABLE_TYPE = 'BASE TABLE' ORDER BY TABLE_NAME`
+	}
+
+	rows, err := db.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables = make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
+}
+
+// InspectColumns reads the columns of a live table, in table order.
+//
+// The table may carry a schema prefix (analytics.events); otherwise the current
+// database or schema is used. An empty list with no error means the table does
+// not exist.
+func InspectColumns(db squirrel.Queryer, flavor string, table string) ([]ColumnInfo, error) {
+	switch flavor {
+	case "postgres":
+		return inspectPgColumns(db, table)
+	case "sqlite3", "sqlite":
+		return inspectSqliteColumns(db, table)
+	default:
+		return inspectMysqlColumns(db, table)
+	}
+}
+
+// splitTable splits an optional schema prefix off a table name.
+func splitTable(table string) (schema sql.NullString, name string) {
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		return sql.NullString{String: table[:i], Valid: true}, table[i+1:]
+	}
+	return sql.NullString{}, table
+}
+
+func inspectMysqlColumns(db squirrel.Queryer, table string) ([]ColumnInfo, error) {
+	schema, name := splitTable(table)
+	rows, err := db.Query(`SELECT COLUMN_NAME, DATA_TYPE, COLUMN_TYPE, IS_NULLABLE, COLUMN_DEFAULT,
+			COLUMN_COMMENT, COLUMN_KEY, EXTRA, CHARACTER_MAXIMUM_LENGTH, NUMERIC_PRECISION, NUMERIC_SCALE
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = COALESCE(?, DATABASE()) AND TABLE_NAME = ?
+		ORDER BY ORDINAL_POSITION`, schema, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cols = make([]ColumnInfo, 0)
+	for rows.Next() {
+		var c ColumnInfo
+		var nullable, key, extra string
+		var length, precision, scale sql.NullInt64
+		err := rows.Scan(&c.Name, &c.DataType, &c.ColumnType, &nullable, &c.Default,
+			&c.Comment, &key, &extra, &length, &precision, &scale)
+		if err != nil {
+			return nil, err
+		}
+		c.DataType = strings.ToLower(c.DataType)
+		c.ColumnType = strings.ToLower(c.ColumnType)
+		c.Nullable = nullable == "YES"
+		c.PrimaryKey = key == "PRI"
+		c.Unique = key == "UNI"
+		c.AutoIncrement = strings.Contains(strings.ToLower(extra), "auto_increment")
+		c.Length = length.Int64
+		if c.DataType == "decimal" {
+			c.Precision, c.Scale = precision.Int64, scale.Int64
+		}
+		cols = append(cols, c)
+	}
+	return cols, rows.Err()
+}
+
+// pgTypes maps postgres udt names to their usual SQL spelling.
+var pgTypes = map[string]string{
+	"int2":   "smallint",
+	"int4":   "integer",
+	"int8":   "bigint",
+	"float4": "real",
+	"float8": "double precision",
+	"bool":   "boolean",
+	"bpchar": "char",
+}
+
+func inspectPgColumns(db squirrel.Queryer, table string) ([]ColumnInfo, error) {
+	schema, name := splitTable(table)
+	rows, err := db.Query(`SELECT c.column_name, c.udt_name, c.is_nullable, c.column_default,
+			COALESCE(col_description(format('%I.%I', c.table_schema, c.table_name)::regclass, c.ordinal_position), ''),
+			c.is_identity, c.character_maximum_length, c.numeric_precision, c.numeric_scale,
+			EXISTS (SELECT 1 FROM pg_index i JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+				WHERE i.indrelid = format('%I.%I', c.table_schema, c.table_name)::regclass
+				AND i.indisprimary AND a.attname = c.column_name),
+			EXISTS (SELECT 1 FROM pg_index i JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = i.indkey[0]
+				WHERE i.indrelid = format('%I.%I', c.table_schema, c.table_name)::regclass
+				AND i.indisunique AND NOT i.indisprimary AND i.indnatts = 1 AND a.attname = c.column_name)
+		FROM information_schema.columns c
+		WHERE c.table_schema = COALESCE($1::text, current_schema()) AND c.table_name = $2
+		ORDER BY c.ordinal_position`, schema, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cols = make([]ColumnInfo, 0)
+	for rows.Next() {
+		var c ColumnInfo
+		var udt, nullable, identity string
+		var length, precision, scale sql.NullInt64
+		err := rows.Scan(&c.Name, &udt, &nullable, &c.Default, &c.Comment,
+			&identity, &length, &precision, &scale, &c.PrimaryKey, &c.Unique)
+		if err != nil {
+			return nil, err
+		}
+
+		var array = strings.HasPrefix(udt, "_")
+		udt = strings.TrimPrefix(udt, "_")
+		if t, ok := pgTypes[udt]; ok {
+			udt = t
+		}
+		c.DataType = udt
+		c.ColumnType = udt
+		switch {
+		case length.Valid:
+			c.ColumnType = fmt.Sprintf("%s(%d)", udt, length.Int64)
+		case udt == "numeric" && precision.Valid:
+			c.ColumnType = fmt.Sprintf("%s(%d,%d)", udt, precision.Int64, scale.Int64)
+		}
+		if array {
+			c.DataType += "[]"
+			c.ColumnType += "[]"
+		}
+
+		c.Nullable = nullable == "YES"
+		c.AutoIncrement = identity == "YES" || strings.HasPrefix(c.Default.String, "nextval(")
+		c.Length = length.Int64
+		if udt == "numeric" {
+			c.Precision, c.Scale = precision.Int64, scale.Int64
+		}
+		cols = append(cols, c)
+	}
+	return cols, rows.Err()
+}
+
+func inspectSqliteColumns(db squirrel.Queryer, table string) ([]ColumnInfo, error) {
+	unique, err := sqliteUniqueColumns(db, table)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`SELECT name, type, "notnull", dflt_value, pk FROM pragma_table_info(?) ORDER BY cid`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cols = make([]ColumnInfo, 0)
+	var keys = 0
+	for rows.Next() {
+		var c ColumnInfo
+		var notNull, pk int
+		if err := rows.Scan(&c.Name, &c.ColumnType, &notNull, &c.Default, &pk); err != nil {
+			return nil, err
+		}
+		c.ColumnType = strings.ToLower(strings.TrimSpace(c.ColumnType))
+		c.DataType = c.ColumnType
+		if i := strings.Index(c.ColumnType, "("); i >= 0 {
+			c.DataType = strings.TrimSpace(c.ColumnType[:i])
+			var a, b int64
+			if n, _ := fmt.Sscanf(c.ColumnType[i:], "(%d,%d)", &a, &b); n == 2 {
+				c.Precision, c.Scale = a, b
+			} else if n == 1 {
+				c.Length = a
+			}
+		}
+		c.Nullable = notNull == 0 && pk == 0
+		c.PrimaryKey = pk > 0
+		c.Unique = unique[c.Name]
+		if pk > 0 {
+			keys++
+		}
+		cols = append(cols, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// A single INTEGER PRIMARY KEY is an alias of the rowid.
+	for i := range cols {
+		if keys == 1 && cols[i].PrimaryKey && cols[i].DataType == "integer" {
+			cols[i].AutoIncrement = true
+		}
+	}
+	return cols, nil
+}
+
+func sqliteUniqueColumns(db squirrel.Queryer, table string) (map[string]bool, error) {
+	rows, err := db.Query(`SELECT MIN(ii.name) FROM pragma_index_list(?) il, pragma_index_info(il.name) ii
+		WHERE il."unique" = 1 AND il.origin <> 'pk' GROUP BY il.name HAVING COUNT(*) = 1`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var unique = make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		unique[name] = true
+	}
+	return unique, rows.Err()
+}
@@ -0,0 +1,126 @@
+package dorm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ListJoin selects the columns of several bound tables at once and scans each
+// row into an R whose fields are the Records of those tables.
+//
+// The first Recorder names the table of the FROM clause. The joins themselves
+// are added by fn:
+//
+// 	type OrderWithCustomer struct {
+// 		Order
+// 		Customer *Customer
+// 	}
+//
+// 	parts := []dorm.Recorder{
+// 		dorm.New(db, "mysql").Bind("orders", &Order{}),
+// 		dorm.New(db, "mysql").Bind("customers", &Customer{}),
+// 	}
+// 	list, err := dorm.ListJoin[OrderWithCustomer](parts, nil, func(q squirrel.SelectBuilder) squirrel.SelectBuilder {
+// 		return q.Join("customers ON customers.id = orders.customer_id").
+// 			Where(squirrel.Eq{"orders.status": "paid"})
+// 	})
+//
+// Every column is selected table-qualified and aliased as table__column, so
+// columns sharing a name (id, created_at, ...) do not clash. Each Recorder is
+// matched to the exported field of R (embedded or not, value or pointer)
+// holding a Record of the same type; each type may appear only once. For a
+// LEFT JOIN the fields of the joined Record must be able to hold NULL.
+func ListJoin[R any](parts []Recorder, pagination *Pagination, fn WhereFunc) ([]R, error) {
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("dorm: ListJoin needs at least one Recorder")
+	}
+
+	rt := reflect.TypeOf((*R)(nil)).Elem()
+	if rt.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("dorm: ListJoin needs a struct result type, got %s", rt)
+	}
+
+	var cols []string
+	var index = make([]int, len(parts))
+	for i, d := range parts {
+		fi, err := joinField(rt, d)
+		if err != nil {
+			return nil, err
+		}
+		index[i] = fi
+
+		tn := d.TableName()
+		alias := strings.ReplaceAll(tn, ".", "_")
+		for _, col := range d.Columns(true) {
+			cols = append(cols, fmt.Sprintf("%s AS %s", Quote(d.Driver(), tn+"."+col), Quote(d.Driver(), alias+"__"+col)))
+		}
+	}
+
+	q := parts[0].Builder().Select(cols...).From(Quote(parts[0].Driver(), parts[0].TableName()))
+	q = fn(q)
+	if pagination != nil && pagination.required() {
+		q = q.Limit(pagination.limit()).Offset(pagination.offset())
+	}
+
+	rows, err := q.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var buf = make([]R, 0)
+	for rows.Next() {
+		var r R
+		rv := reflect.ValueOf(&r).Elem()
+
+		var dest []interface{}
+		for i, d := range parts {
+			fv := rv.Field(index[i])
+			if fv.Kind() == reflect.Ptr {
+				fv.Set(reflect.New(fv.Type().Elem()))
+			} else {
+				fv = fv.Addr()
+			}
+			s := bindNew(d, fv.Interface())
+			dest = append(dest, s.FieldReferences(true)...)
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		buf = append(buf, r)
+	}
+	return buf, rows.Err()
+}
+
+// joinField returns the index of the one field of t holding the Record bound to d.
+func joinField(t reflect.Type, d Recorder) (int, error) {
+	rec := reflect.TypeOf(d.Interface())
+	if rec.Kind() == reflect.Ptr {
+		rec = rec.Elem()
+	}
+
+	var found = -1
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).PkgPath != "" {
+			continue
+		}
+		ft := t.Field(i).Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft != rec {
+			continue
+		}
+		if found >= 0 {
+			return 0, fmt.Errorf("dorm: %s has more than one field of type %s", t, rec)
+		}
+		found = i
+	}
+
+	if found < 0 {
+		return 0, fmt.Errorf("dorm: %s has no field of type %s for table %s", t, rec, d.TableName())
+	}
+	return found, nil
+}
@@ -0,0 +1,77 @@
+package dorm
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+)
+
+type customer struct {
+	Id   int64  `orm:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Name string `orm:"name"`
+}
+
+type orderWithCustomer struct {
+	Order    order
+	Customer *customer
+}
+
+func TestListJoin(t *testing.T) {
+	var page, size uint64 = 2, 2
+	f, d := openFake(t, "mysql")
+	parts := []Recorder{
+		d.Bind("orders", &order{}),
+		New(d.DB(), "mysql").Bind("customers", &customer{}),
+	}
+	f.answer([]string{"orders__id", "orders__status", "orders__amount", "customers__id", "customers__name"},
+		[]driver.Value{int64(1), "paid", 2.5, int64(7), "bo"},
+		[]driver.Value{int64(2), "paid", 1.0, int64(8), "al"})
+
+	got, err := ListJoin[orderWithCustomer](parts, &Pagination{Current: &page, PageSize: &size}, func(q squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return q.Join("customers ON customers.id = orders.customer_id").Where(squirrel.Eq{"orders.status": "paid"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []orderWithCustomer{
+		{order{1, "paid", 2.5}, &customer{7, "bo"}},
+		{order{2, "paid", 1.0}, &customer{8, "al"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListJoin() = %+v, want %+v", got, want)
+	}
+	sql := "SELECT `orders`.`id` AS `orders__id`, `orders`.`status` AS `orders__status`, `orders`.`amount` AS `orders__amount`, " +
+		"`customers`.`id` AS `customers__id`, `customers`.`name` AS `customers__name` FROM `orders` " +
+		"JOIN customers ON customers.id = orders.customer_id WHERE orders.status = ? LIMIT 2 OFFSET 2"
+	if got, _ := f.last(); got != sql {
+		t.Errorf("ListJoin ran %q, want %q", got, sql)
+	}
+
+	tests := []struct {
+		name  string
+		parts []Recorder
+	}{
+		{name: "no recorder", parts: nil},
+		{name: "no matching field", parts: []Recorder{New(d.DB(), "mysql").Bind("accounts", &account{})}},
+	}
+	for _, tt := range tests {
+		if _, err := ListJoin[orderWithCustomer](tt.parts, nil, activeOnly); err == nil {
+			t.Errorf("%s: ListJoin() succeeded", tt.name)
+		}
+	}
+	if _, err := ListJoin[struct {
+		A, B *customer
+	}](parts[1:], nil, activeOnly); err == nil {
+		t.Error("ListJoin() into two fields of the same type succeeded")
+	}
+	if _, err := ListJoin[struct {
+		order
+	}](parts[:1], nil, activeOnly); err == nil {
+		t.Error("ListJoin() into an unexported field succeeded")
+	}
+	if _, err := ListJoin[int64](parts, nil, activeOnly); err == nil {
+		t.Error("ListJoin() into a non-struct succeeded")
+	}
+}
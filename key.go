@@ -0,0 +1,140 @@
+package dorm
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// keyWhere matches the rows whose primary key is key.
+func keyWhere(d Recorder, key Tuple) (squirrel.Eq, error) {
+	var cols = keyOf(d)
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("dorm: %s has no PRIMARY_KEY", d.TableName())
+	}
+	if len(key) != len(cols) {
+		return nil, fmt.Errorf("dorm: %s has a %d column key, got %d values", d.TableName(), len(cols), len(key))
+	}
+
+	var eq = make(squirrel.Eq, len(cols))
+	for i, col := range cols {
+		eq[Quote(d.Driver(), col)] = key[i]
+	}
+	return eq, nil
+}
+
+// SetKey sets the primary key fields of the bound Record, in the order given
+// by Key(). Values are converted to the field types when possible; if one
+// cannot be, no field is set.
+func (s *DbRecorder) SetKey(key Tuple) error {
+	if _, err := keyWhere(s, key); err != nil {
+		return err
+	}
+
+	// Convert every value before setting any, so a bad key leaves the Record as is.
+	ar := reflect.Indirect(reflect.ValueOf(s.record))
+	var fields, values = make([]reflect.Value, len(s.key)), make([]reflect.Value, len(s.key))
+	for i, f := range s.key {
+		fields[i] = fieldByIndex(ar, f.index, true)
+		ft := fields[i].Type()
+		v := reflect.ValueOf(key[i])
+		switch {
+		case !v.IsValid():
+			values[i] = reflect.Zero(ft)
+		case v.Type().AssignableTo(ft):
+			values[i] = v
+		case v.Type().ConvertibleTo(ft):
+			values[i] = v.Convert(ft)
+		default:
+			return fmt.Errorf("dorm: cannot use %T as %s of %s", key[i], f.column, s.table)
+		}
+	}
+	for i, fv := range fields {
+		fv.Set(values[i])
+	}
+	return nil
+}
+
+// LoadByKey sets the primary key of the bound Record to key, then loads it:
+//
+// 	err := d.LoadByKey(dorm.Tuple{orderId, lineNo})
+//
+// ErrNotFound is returned when no row matches.
+func (s *DbRecorder) LoadByKey(key Tuple) error {
+	if err := s.SetKey(key); err != nil {
+		return err
+	}
+	return s.Load()
+}
+
+// ExistsByKey reports whether a row has the primary key key. The bound Record
+// is left untouched.
+func (s *DbRecorder) ExistsByKey(key Tuple) (bool, error) {
+	where, err := keyWhere(s, key)
+	if err != nil {
+		return false, err
+	}
+	return s.ExistsWhere(where)
+}
+
+// DeleteByKey deletes the row with the primary key key. The bound Record is
+// left untouched; RowsAffected and VerifyAffected apply as for Delete.
+//
+// The delete hooks run as for Delete, given a clone of s bound to a new Record
+// holding only the key.
+func (s *DbRecorder) DeleteByKey(key Tuple) error {
+	where, err := keyWhere(s, key)
+	if err != nil {
+		return err
+	}
+
+	var d Recorder = s
+	if s.hooks != nil {
+		d = s.Clone().Bind(s.table, reflect.New(reflect.Indirect(reflect.ValueOf(s.record)).Type()).Interface())
+		if err := d.(*DbRecorder).SetKey(key); err != nil {
+			return err
+		}
+	}
+	return s.hooks.around(d, hookDelete, func() error {
+		q := s.builder.Delete(Quote(s.flavor, s.table)).Where(where)
+		return s.affectedRows(q.Exec())
+	})
+}
+
+// ListByKeys returns the rows whose primary key is one of keys, in no
+// particular order. Keys without a row are skipped.
+//
+// A single column key is looked up with IN; composite keys with one
+// condition per key, so keep batches reasonably small.
+func ListByKeys(d Recorder, keys []Tuple) ([]Recorder, error) {
+	if len(keys) == 0 {
+		return []Recorder{}, nil
+	}
+
+	var pred squirrel.Sqlizer
+	if cols := keyOf(d); len(cols) == 1 {
+		var in = make([]interface{}, len(keys))
+		for i, key := range keys {
+			if len(key) != 1 {
+				return nil, fmt.Errorf("dorm: %s has a 1 column key, got %d values", d.TableName(), len(key))
+			}
+			in[i] = key[0]
+		}
+		pred = squirrel.Eq{Quote(d.Driver(), cols[0]): in}
+	} else {
+		var or = make(squirrel.Or, len(keys))
+		for i, key := range keys {
+			where, err := keyWhere(d, key)
+			if err != nil {
+				return nil, err
+			}
+			or[i] = where
+		}
+		pred = or
+	}
+
+	return ListWhere(d, nil, func(q squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return q.Where(pred)
+	})
+}
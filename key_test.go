@@ -0,0 +1,229 @@
+package dorm
+
+import (
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// line has a composite key.
+type line struct {
+	OrderId int64  `orm:"order_id,PRIMARY_KEY"`
+	LineNo  int32  `orm:"line_no,PRIMARY_KEY"`
+	Sku     string `orm:"sku" columnDefinition:"varchar(16)"`
+}
+
+// counter has an unsigned AUTO_INCREMENT key.
+type counter struct {
+	Id uint64 `orm:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	N  int64  `orm:"n"`
+}
+
+// revision has an AUTO_INCREMENT column last in its composite key.
+type revision struct {
+	DocId int64 `orm:"doc_id,PRIMARY_KEY"`
+	Rev   int64 `orm:"rev,PRIMARY_KEY,AUTO_INCREMENT"`
+}
+
+// event has an AUTO_INCREMENT column outside its key.
+type event struct {
+	Uuid string `orm:"uuid,PRIMARY_KEY" columnDefinition:"char(36)"`
+	Seq  int64  `orm:"seq,AUTO_INCREMENT"`
+}
+
+func TestCompositeKeySchema(t *testing.T) {
+	tests := []struct {
+		flavor string
+		record Record
+		want   string
+	}{
+		{
+			flavor: "mysql", record: &line{},
+			want: "create table IF NOT EXISTS `lines` (`order_id` bigint default 0 comment '',\n" +
+				"`line_no` int default 0 comment '',\n`sku` varchar(16) comment '',\n" +
+				"PRIMARY KEY (`order_id`, `line_no`)) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;",
+		},
+		{
+			flavor: "sqlite3", record: &line{},
+			want: `create table IF NOT EXISTS "lines" ("order_id" bigint default 0,` + "\n" +
+				`"line_no" int default 0,` + "\n" + `"sku" varchar(16),` + "\n" +
+				`PRIMARY KEY ("order_id", "line_no"));`,
+		},
+		{
+			flavor: "mysql", record: &counter{},
+			want: "create table IF NOT EXISTS `lines` (`id` bigint auto_increment comment '',\n`n` bigint default 0 comment '',\n" +
+				"PRIMARY KEY (`id`)) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;",
+		},
+		{
+			flavor: "postgres", record: &counter{},
+			want: `create table IF NOT EXISTS "lines" ("id" bigserial,` + "\n" + `"n" bigint default 0,` + "\n" + `PRIMARY KEY ("id"));`,
+		},
+		{
+			flavor: "sqlite3", record: &counter{},
+			want: `create table IF NOT EXISTS "lines" ("id" integer primary key autoincrement,` + "\n" + `"n" bigint default 0);`,
+		},
+		{
+			flavor: "mysql", record: &revision{},
+			want: "create table IF NOT EXISTS `lines` (`doc_id` bigint default 0 comment '',\n`rev` bigint auto_increment comment '',\n" +
+				"PRIMARY KEY (`rev`, `doc_id`)) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;",
+		},
+		{
+			flavor: "postgres", record: &revision{},
+			want: `create table IF NOT EXISTS "lines" ("doc_id" bigint default 0,` + "\n" + `"rev" bigserial,` + "\n" + `PRIMARY KEY ("doc_id", "rev"));`,
+		},
+		{
+			flavor: "mysql", record: &event{},
+			want: "create table IF NOT EXISTS `lines` (`uuid` char(36) comment '',\n`seq` bigint auto_increment comment '',\n" +
+				"PRIMARY KEY (`uuid`),\nKEY (`seq`)) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;",
+		},
+	}
+	for _, tt := range tests {
+		_, d := openFake(t, tt.flavor)
+		d.Bind("lines", tt.record)
+		if got := d.GetSchema(); got != tt.want {
+			t.Errorf("%s %T: GetSchema() =\n%s\nwant\n%s", tt.flavor, tt.record, got, tt.want)
+		}
+	}
+}
+
+func TestLoadByKey(t *testing.T) {
+	f, d := openFake(t, "mysql")
+	rec := &line{}
+	d.Bind("lines", rec)
+
+	f.answer([]string{"sku"}, []driver.Value{"ab-1"})
+	if err := d.LoadByKey(Tuple{7, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if *rec != (line{7, 2, "ab-1"}) {
+		t.Errorf("LoadByKey() loaded %+v", rec)
+	}
+	sql, args := f.last()
+	if sql != "SELECT `sku` FROM `lines` WHERE `line_no` = ? AND `order_id` = ?" || !reflect.DeepEqual(args, []driver.Value{int64(2), int64(7)}) {
+		t.Errorf("LoadByKey() ran %q %v", sql, args)
+	}
+
+	if err := d.LoadByKey(Tuple{8, 1}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("LoadByKey() of a missing row = %v, want ErrNotFound", err)
+	}
+
+	d.SetKey(Tuple{7, 2})
+	for _, key := range []Tuple{{7}, {7, 2, 3}, {8, "two"}} {
+		if err := d.SetKey(key); err == nil || *rec != (line{7, 2, "ab-1"}) {
+			t.Errorf("SetKey(%v) = %v, record %+v", key, err, rec)
+		}
+	}
+
+	if err := d.DeleteByKey(Tuple{7, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if sql, _ := f.last(); sql != "DELETE FROM `lines` WHERE `line_no` = ? AND `order_id` = ?" {
+		t.Errorf("DeleteByKey() ran %q", sql)
+	}
+	if *rec != (line{7, 2, "ab-1"}) {
+		t.Errorf("DeleteByKey() changed the record to %+v", rec)
+	}
+}
+
+func TestDeleteByKeyHooks(t *testing.T) {
+	f, _ := openFake(t, "mysql")
+	db := NewDB(squirrel.NewStmtCacheProxy(f.db), "mysql")
+	var seen []line
+	record := func(d Recorder) error {
+		seen = append(seen, *d.Interface().(*line))
+		return nil
+	}
+	db.Hooks.BeforeDelete = []Hook{record}
+	db.Hooks.AfterDelete = []Hook{record}
+
+	rec := &line{OrderId: 1, LineNo: 1, Sku: "ab-1"}
+	d := db.Model(rec)
+	if err := d.DeleteByKey(Tuple{7, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []line{{7, 2, ""}, {7, 2, ""}}; !reflect.DeepEqual(seen, want) {
+		t.Errorf("hooks saw %+v, want %+v", seen, want)
+	}
+	if *rec != (line{1, 1, "ab-1"}) || d.RowsAffected() != 1 {
+		t.Errorf("DeleteByKey() left record %+v, %d rows affected", rec, d.RowsAffected())
+	}
+
+	refused := errors.New("refused")
+	db.Hooks.BeforeDelete = []Hook{func(d Recorder) error { return refused }}
+	var stmts = len(f.stmts)
+	if err := d.DeleteByKey(Tuple{7, 3}); !errors.Is(err, refused) || len(f.stmts) != stmts {
+		t.Errorf("DeleteByKey() with a failing hook = %v, ran %q", err, f.stmts[stmts:])
+	}
+}
+
+func TestListByKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		table  string
+		record Record
+		keys   []Tuple
+		sql    string
+		nargs  int
+		fail   bool
+	}{
+		{name: "no keys", table: "lines", record: &line{}, keys: nil},
+		{
+			name: "single column", table: "accounts", record: &account{}, keys: []Tuple{{1}, {2}, {3}},
+			sql: "SELECT `id`, `name` FROM `accounts` WHERE `id` IN (?,?,?)", nargs: 3,
+		},
+		{
+			name: "composite", table: "lines", record: &line{}, keys: []Tuple{{1, 1}, {1, 2}},
+			sql: "SELECT `order_id`, `line_no`, `sku` FROM `lines` WHERE (`line_no` = ? AND `order_id` = ? OR `line_no` = ? AND `order_id` = ?)", nargs: 4,
+		},
+		{name: "short key", table: "lines", record: &line{}, keys: []Tuple{{1, 1}, {1}}, fail: true},
+		{name: "long key", table: "accounts", record: &account{}, keys: []Tuple{{1, 1}}, fail: true},
+	}
+	for _, tt := range tests {
+		f, d := openFake(t, "mysql")
+		d.Bind(tt.table, tt.record)
+
+		_, err := ListByKeys(d, tt.keys)
+		if tt.fail {
+			if err == nil {
+				t.Errorf("%s: ListByKeys() succeeded", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+		sql, args := f.last()
+		if sql != tt.sql || len(args) != tt.nargs {
+			t.Errorf("%s: ran %q %v, want %q", tt.name, sql, args, tt.sql)
+		}
+	}
+}
+
+func TestInsertSetsUnsignedAutoId(t *testing.T) {
+	f, d := openFake(t, "mysql")
+	f.lastID = 42
+	rec := &counter{N: 1}
+	if err := d.Bind("counters", rec).Insert(); err != nil || rec.Id != 42 {
+		t.Errorf("Insert() = %v, id %d", err, rec.Id)
+	}
+}
+
+// plainRecorder is a Recorder that is not a Keyer.
+type plainRecorder struct {
+	Recorder
+}
+
+func TestKeyOf(t *testing.T) {
+	_, d := openFake(t, "mysql")
+	d.Bind("lines", &line{})
+
+	if got := keyOf(d); !reflect.DeepEqual(got, []string{"order_id", "line_no"}) {
+		t.Errorf("keyOf(DbRecorder) = %v", got)
+	}
+	if got := keyOf(plainRecorder{d}); !reflect.DeepEqual(got, []string{"line_no", "order_id"}) {
+		t.Errorf("keyOf(Recorder) = %v, want the WhereIds columns in order", got)
+	}
+}
@@ -0,0 +1,96 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dengsibao/dorm"
+)
+
+// Create writes the up and down files of a new migration to dir, numbered
+// after the highest version found there, and returns the path of the up file.
+//
+// The statements come from the given models, usually DB.Models(): for each,
+// the SchemaDiff of the bound Record against the live table, so a new model
+// gets its CREATE TABLE and a new field an ADD COLUMN. When nothing differs,
+// the files only hold a comment, to be filled in by hand. Either way they are
+// a starting point meant to be reviewed.
+func (m *Migrator) Create(dir, name string, models ...*dorm.DbRecorder) (string, error) {
+	var up, down []string
+	for _, d := range models {
+		live, err := dorm.InspectColumns(m.db, m.flavor, d.TableName())
+		if err != nil {
+			return "", fmt.Errorf("migrate: inspecting %s: %v", d.TableName(), err)
+		}
+		u, dn := d.SchemaDiff(live)
+		up = append(up, u...)
+		down = append(dn, down...)
+	}
+	return create(dir, name, up, down)
+}
+
+// create writes a new migration with the given statements to dir.
+func create(dir, name string, up, down []string) (string, error) {
+	name = slug(name)
+	if name == "" {
+		return "", fmt.Errorf("migrate: empty migration name")
+	}
+
+	var last int64
+	migrations, err := Load(os.DirFS(dir))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	for _, mg := range migrations {
+		if mg.Version > last {
+			last = mg.Version
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	var base = filepath.Join(dir, fmt.Sprintf("%04d_%s", last+1, name))
+	if err := os.WriteFile(base+".up.sql", script(name, "up", up), 0o644); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(base+".down.sql", script(name, "down", down), 0o644); err != nil {
+		return "", err
+	}
+	return base + ".up.sql", nil
+}
+
+func script(name, direction string, stmts []string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "-- %s (%s)\n", name, direction)
+	if len(stmts) == 0 {
+		b.WriteString("-- TODO: write the statements of this migration\n")
+	}
+	for _, stmt := range stmts {
+		b.WriteString("\n" + stmt + "\n")
+	}
+	return []byte(b.String())
+}
+
+// slug turns a description into a file name part: add user email becomes
+// add_user_email.
+func slug(name string) string {
+	var b strings.Builder
+	var sep = false
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			if sep && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+			sep = false
+		} else {
+			sep = true
+		}
+	}
+	return b.String()
+}
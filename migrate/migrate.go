@@ -0,0 +1,103 @@
+// Package migrate applies versioned SQL migrations.
+//
+// Migrations are pairs of files named after their version and purpose:
+//
+// 	0001_create_users.up.sql
+// 	0001_create_users.down.sql
+// 	0002_add_user_email.up.sql
+// 	...
+//
+// read from a directory or an embed.FS. The applied versions are recorded in
+// the schema_migrations table; a Migrator applies the missing ones in version
+// order, and reverts the latest ones with their down files:
+//
+// 	//go:embed migrations/*.sql
+// 	var files embed.FS
+//
+// 	sub, _ := fs.Sub(files, "migrations")
+// 	m, err := migrate.New(db, "mysql", sub)
+// 	if err != nil {
+// 		return err
+// 	}
+// 	err = m.Up()
+//
+// Each migration runs in a transaction with the update of schema_migrations.
+// MySQL commits DDL statements implicitly, so a failing MySQL migration may
+// be left half applied.
+//
+// On MySQL and Postgres, a Migrator holds an advisory lock while it runs, so
+// that several instances starting at once apply each migration once. Sqlite
+// has no such lock: run the migrations of a sqlite database from a single
+// process.
+package migrate
+
+import (
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// Migration is a version of the schema.
+type Migration struct {
+	Version int64
+	Name    string
+	// Up and Down hold the SQL of the up and down files. Down is empty when
+	// there is no down file.
+	Up, Down string
+}
+
+var fileRe = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Load reads the migrations at the root of fsys, in version order. Files
+// that do not look like migrations are ignored. Every version needs an up
+// file; the down file is optional.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	var byVersion = make(map[int64]*Migration)
+	var hasUp = make(map[int64]bool)
+	for _, e := range entries {
+		match := fileRe.FindStringSubmatch(e.Name())
+		if e.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migrate: bad version in %s", e.Name())
+		}
+		data, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migrate: version %d is both %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up, hasUp[version] = string(data), true
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	var migrations = make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if !hasUp[m.Version] {
+			return nil, fmt.Errorf("migrate: version %d (%s) has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
@@ -0,0 +1,379 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/dengsibao/dorm"
+)
+
+// DefaultTable is the table recording the applied versions.
+const DefaultTable = "schema_migrations"
+
+// Migrator applies the migrations of a directory to a database.
+type Migrator struct {
+	// Table records the applied versions, DefaultTable by default.
+	Table string
+	// Logf, if set, reports each migration applied or reverted.
+	Logf func(format string, args ...interface{})
+
+	db         *sql.DB
+	flavor     string
+	migrations []Migration
+}
+
+// Status is the state of a migration.
+type Status struct {
+	Version int64
+	Name    string
+	// Applied reports whether the version is recorded, at AppliedAt.
+	Applied   bool
+	AppliedAt dorm.Time
+	// Missing reports an applied version without migration files.
+	Missing bool
+}
+
+// New returns a Migrator for the migrations at the root of fsys, which may be
+// os.DirFS(dir) or an embed.FS. flavor is a dorm flavor: mysql, postgres or
+// sqlite3.
+func New(db *sql.DB, flavor string, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{Table: DefaultTable, db: db, flavor: flavor, migrations: migrations}, nil
+}
+
+// Migrations returns the migrations read by New, in version order.
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Up applies every migration not applied yet, in version order. This
+// includes versions older than the latest applied one, as when branches
+// adding migrations are merged.
+func (m *Migrator) Up() error {
+	return m.run(func(conn *sql.Conn, applied map[int64]bool) error {
+		for _, mg := range m.migrations {
+			if applied[mg.Version] {
+				continue
+			}
+			if err := m.apply(conn, mg, true); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Down reverts the last n applied migrations, latest first. It fails before
+// reverting anything if one of them has no down file.
+func (m *Migrator) Down(n int) error {
+	return m.run(func(conn *sql.Conn, applied map[int64]bool) error {
+		var revert []Migration
+		for i := len(m.migrations) - 1; i >= 0 && len(revert) < n; i-- {
+			if applied[m.migrations[i].Version] {
+				revert = append(revert, m.migrations[i])
+			}
+		}
+		return m.revert(conn, revert)
+	})
+}
+
+// To migrates to version: the migrations up to it are applied, and the
+// applied ones after it are reverted, latest first. Version 0 reverts
+// everything.
+func (m *Migrator) To(version int64) error {
+	if version != 0 && m.find(version) < 0 {
+		return fmt.Errorf("migrate: no migration %d", version)
+	}
+
+	return m.run(func(conn *sql.Conn, applied map[int64]bool) error {
+		var revert []Migration
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			if mg := m.migrations[i]; mg.Version > version && applied[mg.Version] {
+				revert = append(revert, mg)
+			}
+		}
+		if err := m.revert(conn, revert); err != nil {
+			return err
+		}
+
+		for _, mg := range m.migrations {
+			if mg.Version > version {
+				break
+			}
+			if applied[mg.Version] {
+				continue
+			}
+			if err := m.apply(conn, mg, true); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Status lists the migrations with their state, in version order, followed
+// by the applied versions that have no files.
+func (m *Migrator) Status() ([]Status, error) {
+	ctx := context.Background()
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	unlock, err := m.lock(conn)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if err := m.createTable(conn); err != nil {
+		return nil, err
+	}
+	rows, err := query(conn, m.builder().Select("version", "name", "applied_at").
+		From(dorm.Quote(m.flavor, m.Table)).OrderBy("version"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recorded = make(map[int64]Status)
+	for rows.Next() {
+		var st = Status{Applied: true}
+		if err := rows.Scan(&st.Version, &st.Name, m.appliedAt(&st.AppliedAt)); err != nil {
+			return nil, err
+		}
+		recorded[st.Version] = st
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var status = make([]Status, 0, len(m.migrations))
+	for _, mg := range m.migrations {
+		st, ok := recorded[mg.Version]
+		if !ok {
+			st = Status{Version: mg.Version}
+		}
+		st.Name = mg.Name
+		status = append(status, st)
+		delete(recorded, mg.Version)
+	}
+
+	var missing = make([]Status, 0, len(recorded))
+	for _, st := range recorded {
+		st.Missing = true
+		missing = append(missing, st)
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		return missing[i].Version < missing[j].Version
+	})
+	return append(status, missing...), nil
+}
+
+func (m *Migrator) find(version int64) int {
+	for i, mg := range m.migrations {
+		if mg.Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+// run calls fn with a connection holding the migration lock, and the
+// versions applied.
+func (m *Migrator) run(fn func(conn *sql.Conn, applied map[int64]bool) error) error {
+	ctx := context.Background()
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	unlock, err := m.lock(conn)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := m.createTable(conn); err != nil {
+		return err
+	}
+
+	rows, err := query(conn, m.builder().Select("version").From(dorm.Quote(m.flavor, m.Table)))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var applied = make(map[int64]bool)
+	for rows.Next() {
+		var v int64
+		if err := rows.Scan(&v); err != nil {
+			return err
+		}
+		applied[v] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	return fn(conn, applied)
+}
+
+// revert reverts migrations in the given order, after checking that they
+// all have a down file.
+func (m *Migrator) revert(conn *sql.Conn, migrations []Migration) error {
+	for _, mg := range migrations {
+		if strings.TrimSpace(mg.Down) == "" {
+			return fmt.Errorf("migrate: version %d (%s) has no down file", mg.Version, mg.Name)
+		}
+	}
+	for _, mg := range migrations {
+		if err := m.apply(conn, mg, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply runs the up or down SQL of a migration, and records it, in one
+// transaction.
+func (m *Migrator) apply(conn *sql.Conn, mg Migration, up bool) (err error) {
+	var script, verb = mg.Up, "applied"
+	if !up {
+		script, verb = mg.Down, "reverted"
+	}
+
+	tx, err := conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	for _, stmt := range split(script, m.flavor) {
+		if _, err = tx.Exec(stmt); err != nil {
+			return fmt.Errorf("migrate: %d_%s: %v", mg.Version, mg.Name, err)
+		}
+	}
+
+	var table = dorm.Quote(m.flavor, m.Table)
+	if up {
+		_, err = m.builder().Insert(table).Columns("version", "name").Values(mg.Version, mg.Name).RunWith(tx).Exec()
+	} else {
+		_, err = m.builder().Delete(table).Where(squirrel.Eq{"version": mg.Version}).RunWith(tx).Exec()
+	}
+	if err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	if m.Logf != nil {
+		m.Logf("%s %d_%s", verb, mg.Version, mg.Name)
+	}
+	return nil
+}
+
+// query runs q on conn; squirrel only runs queries on a *sql.DB or *sql.Tx.
+func query(conn *sql.Conn, q squirrel.SelectBuilder) (*sql.Rows, error) {
+	stmt, args, err := q.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	return conn.QueryContext(context.Background(), stmt, args...)
+}
+
+func (m *Migrator) builder() squirrel.StatementBuilderType {
+	if m.flavor == "postgres" {
+		return squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	}
+	return squirrel.StatementBuilder
+}
+
+func (m *Migrator) createTable(conn *sql.Conn) error {
+	_, err := conn.ExecContext(context.Background(), fmt.Sprintf(`create table IF NOT EXISTS %s (
+version bigint not null primary key,
+name varchar(255) not null default '',
+applied_at timestamp not null default CURRENT_TIMESTAMP)`, dorm.Quote(m.flavor, m.Table)))
+	return err
+}
+
+// appliedAt returns the destination scanning applied_at into t. Sqlite
+// writes CURRENT_TIMESTAMP as UTC text, which dorm.Time would read in
+// dorm.TimeLocation.
+func (m *Migrator) appliedAt(t *dorm.Time) sql.Scanner {
+	if m.flavor != "sqlite3" && m.flavor != "sqlite" {
+		return t
+	}
+	return utcTime{t}
+}
+
+// utcTime scans a datetime string without offset as UTC into a dorm.Time.
+type utcTime struct {
+	t *dorm.Time
+}
+
+func (u utcTime) Scan(v interface{}) error {
+	if b, ok := v.([]byte); ok {
+		v = string(b)
+	}
+	if s, ok := v.(string); ok {
+		if tt, err := time.ParseInLocation(dorm.DatetimeLayout, s, time.UTC); err == nil {
+			*u.t = dorm.NewTime(tt)
+			return nil
+		}
+	}
+	return u.t.Scan(v)
+}
+
+// lock takes an advisory lock named after the migration table, so that
+// concurrent runs apply each migration once, and create the table once:
+// GET_LOCK on mysql, pg_advisory_lock on postgres. Sqlite has no such lock;
+// there, concurrent runs are only kept apart by the primary key of the
+// migration table.
+func (m *Migrator) lock(conn *sql.Conn) (unlock func(), err error) {
+	ctx := context.Background()
+	switch m.flavor {
+	case "postgres":
+		h := fnv.New64a()
+		h.Write([]byte("dorm:" + m.Table))
+		key := int64(h.Sum64())
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", key); err != nil {
+			return nil, fmt.Errorf("migrate: lock: %v", err)
+		}
+		return func() {
+			conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", key)
+		}, nil
+
+	case "sqlite3", "sqlite":
+		return func() {}, nil
+
+	default:
+		var name = "dorm:" + m.Table
+		var got sql.NullInt64
+		if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(CONCAT(DATABASE(), ?), -1)", name).Scan(&got); err != nil {
+			return nil, fmt.Errorf("migrate: lock: %v", err)
+		}
+		if got.Int64 != 1 {
+			return nil, fmt.Errorf("migrate: lock: GET_LOCK failed")
+		}
+		return func() {
+			conn.ExecContext(ctx, "SELECT RELEASE_LOCK(CONCAT(DATABASE(), ?))", name)
+		}, nil
+	}
+}
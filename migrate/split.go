@@ -0,0 +1,83 @@
+package migrate
+
+import "strings"
+
+// split splits a script into its statements, at the semicolons outside of
+// quotes, comments and postgres dollar-quoted bodies. Statements holding only
+// whitespace and comments are dropped. On mysql, # starts a comment too and
+// backslashes escape quotes.
+//
+// Scripts are run statement by statement because most drivers, the mysql one
+// without multiStatements included, run a single statement per Exec.
+func split(script, flavor string) []string {
+	var mysql = flavor != "postgres" && flavor != "sqlite3" && flavor != "sqlite"
+	var stmts []string
+	var start = 0
+	var blank = true
+
+	flush := func(end int) {
+		if stmt := strings.TrimSpace(script[start:end]); stmt != "" && !blank {
+			stmts = append(stmts, stmt)
+		}
+		start, blank = end+1, true
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == '-' && strings.HasPrefix(script[i:], "--"), c == '#' && mysql:
+			if j := strings.IndexByte(script[i:], '\n'); j >= 0 {
+				i += j
+			} else {
+				i = len(script)
+			}
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			if j := strings.Index(script[i+2:], "*/"); j >= 0 {
+				i += j + 3
+			} else {
+				i = len(script)
+			}
+		case c == '\'' || c == '"' || c == '`':
+			blank = false
+			for i++; i < len(script); i++ {
+				if script[i] == '\\' && mysql {
+					i++
+				} else if script[i] == c {
+					break
+				}
+			}
+		case c == '$' && !mysql:
+			blank = false
+			if tag := dollarTag(script[i:]); tag != "" {
+				if j := strings.Index(script[i+len(tag):], tag); j >= 0 {
+					i += len(tag) + j + len(tag) - 1
+				} else {
+					i = len(script)
+				}
+			}
+		case c == ';':
+			flush(i)
+		case c != ' ' && c != '\t' && c != '\n' && c != '\r':
+			blank = false
+		}
+	}
+	if start < len(script) {
+		flush(len(script))
+	}
+	return stmts
+}
+
+// dollarTag returns the $tag$ opening s, if any.
+func dollarTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '$':
+			return s[:i+1]
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 1 && c >= '0' && c <= '9':
+		default:
+			return ""
+		}
+	}
+	return ""
+}
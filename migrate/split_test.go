@@ -0,0 +1,93 @@
+package migrate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name   string
+		flavor string
+		script string
+		want   []string
+	}{
+		{
+			name:   "statements",
+			flavor: "mysql",
+			script: "create table a (id int);\ncreate table b (id int);\n",
+			want:   []string{"create table a (id int)", "create table b (id int)"},
+		},
+		{
+			name:   "no trailing semicolon",
+			flavor: "sqlite3",
+			script: "select 1; select 2",
+			want:   []string{"select 1", "select 2"},
+		},
+		{
+			name:   "semicolons in quotes",
+			flavor: "postgres",
+			script: `insert into a values ('x;y', "c;d"); select 'it''s; ok';`,
+			want:   []string{`insert into a values ('x;y', "c;d")`, `select 'it''s; ok'`},
+		},
+		{
+			name:   "mysql backslash escapes",
+			flavor: "mysql",
+			script: "insert into a values ('a\\';b'); select `c;d`",
+			want:   []string{"insert into a values ('a\\';b')", "select `c;d`"},
+		},
+		{
+			name:   "postgres backslash is literal",
+			flavor: "postgres",
+			script: `select 'a\'; select 2`,
+			want:   []string{`select 'a\'`, "select 2"},
+		},
+		{
+			name:   "comments",
+			flavor: "mysql",
+			script: "-- drop; nothing\n/* a; b */\n# c;\ncreate table a (id int); -- trailing;",
+			want:   []string{"-- drop; nothing\n/* a; b */\n# c;\ncreate table a (id int)"},
+		},
+		{
+			name:   "hash is not a comment on postgres",
+			flavor: "postgres",
+			script: "select 1 # 2; select 3",
+			want:   []string{"select 1 # 2", "select 3"},
+		},
+		{
+			name:   "empty statements and comment-only scripts",
+			flavor: "sqlite3",
+			script: ";;\n -- only a comment;\n/* and; another */",
+			want:   nil,
+		},
+		{
+			name:   "dollar-quoted bodies",
+			flavor: "postgres",
+			script: `create function f() returns int as $$ begin return 1; end; $$ language plpgsql;
+create function g() returns text as $body$ select 'a;$$;b'; $body$ language sql;
+select $1;`,
+			want: []string{
+				`create function f() returns int as $$ begin return 1; end; $$ language plpgsql`,
+				`create function g() returns text as $body$ select 'a;$$;b'; $body$ language sql`,
+				`select $1`,
+			},
+		},
+		{
+			name:   "dollar signs on mysql",
+			flavor: "mysql",
+			script: "select '$$'; select 1",
+			want:   []string{"select '$$'", "select 1"},
+		},
+		{
+			name:   "unterminated dollar quote",
+			flavor: "postgres",
+			script: "select $x$ a; b",
+			want:   []string{"select $x$ a; b"},
+		},
+	}
+	for _, tt := range tests {
+		if got := split(tt.script, tt.flavor); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: split() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
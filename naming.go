@@ -0,0 +1,98 @@
+package dorm
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// NamingStrategy derives table and column names from Go names, for Records
+// bound without a table name and for fields without a name in their 'orm' tag.
+type NamingStrategy interface {
+	// TableName returns the table of a struct type, given its name.
+	TableName(typeName string) string
+	// ColumnName returns the column of a struct field, given its name.
+	ColumnName(fieldName string) string
+}
+
+// SnakeNaming is the default NamingStrategy: names are snake cased, keeping
+// acronyms together, so UserID is user_id and HTTPServer is http_server.
+type SnakeNaming struct {
+	// Prefix is prepended to the table names it derives.
+	Prefix string
+	// Plural turns OrderItem into order_items rather than order_item.
+	Plural bool
+}
+
+// TableName implements NamingStrategy.
+func (n SnakeNaming) TableName(typeName string) string {
+	var table = SnakeCase(typeName)
+	if n.Plural {
+		table = plural(table)
+	}
+	return n.Prefix + table
+}
+
+// ColumnName implements NamingStrategy.
+func (n SnakeNaming) ColumnName(fieldName string) string {
+	return SnakeCase(fieldName)
+}
+
+// Naming is the NamingStrategy used by Bind and the field scanning, unless the
+// Recorder comes from a DB with its own. Set it before binding any Record:
+//
+// 	dorm.Naming = dorm.SnakeNaming{Prefix: "shop_", Plural: true}
+var Naming NamingStrategy = SnakeNaming{}
+
+// tableNamer is implemented by Records naming their own table.
+type tableNamer interface {
+	TableName() string
+}
+
+// tableOf returns the table of a Record bound without a table name: the
+// result of its TableName method if it has one, or else the name given by
+// naming to its type.
+func tableOf(ar Record, naming NamingStrategy) string {
+	if tn, ok := ar.(tableNamer); ok {
+		return tn.TableName()
+	}
+	return naming.TableName(reflect.Indirect(reflect.ValueOf(ar)).Type().Name())
+}
+
+// SnakeCase turns a Go name into snake_case. A run of capitals is kept as one
+// word, its last capital starting the next word when followed by lower case:
+//
+// 	UserID     -> user_id
+// 	HTTPServer -> http_server
+// 	Address2   -> address2
+func SnakeCase(name string) string {
+	var runes = []rune(name)
+	var b strings.Builder
+	b.Grow(len(name) + 4)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && runes[i-1] != '_' {
+			prev := runes[i-1]
+			next := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && next) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// plural returns the English plural of the last word of a snake_case name.
+func plural(name string) string {
+	var lower = strings.ToLower(name)
+	switch {
+	case lower == "" || strings.HasSuffix(lower, "_"):
+		return name
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"), strings.HasSuffix(lower, "z"),
+		strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+		return name + "es"
+	case strings.HasSuffix(lower, "y") && len(lower) > 1 && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		return name[:len(name)-1] + "ies"
+	}
+	return name + "s"
+}
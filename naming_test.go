@@ -0,0 +1,97 @@
+package dorm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSnakeCase(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"Id", "id"},
+		{"ID", "id"},
+		{"UserID", "user_id"},
+		{"UserId", "user_id"},
+		{"HTTPServer", "http_server"},
+		{"ServeHTTP", "serve_http"},
+		{"JSONToXML", "json_to_xml"},
+		{"Address2", "address2"},
+		{"Line2Text", "line2_text"},
+		{"already_snake", "already_snake"},
+		{"Mixed_Case", "mixed_case"},
+		{"A", "a"},
+		{"ÜberName", "über_name"},
+	}
+	for _, tt := range tests {
+		if got := SnakeCase(tt.in); got != tt.want {
+			t.Errorf("SnakeCase(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSnakeNaming(t *testing.T) {
+	tests := []struct {
+		naming   SnakeNaming
+		typeName string
+		want     string
+	}{
+		{SnakeNaming{}, "OrderItem", "order_item"},
+		{SnakeNaming{Plural: true}, "OrderItem", "order_items"},
+		{SnakeNaming{Plural: true}, "Address", "addresses"},
+		{SnakeNaming{Plural: true}, "Box", "boxes"},
+		{SnakeNaming{Plural: true}, "Batch", "batches"},
+		{SnakeNaming{Plural: true}, "Category", "categories"},
+		{SnakeNaming{Plural: true}, "Key", "keys"},
+		{SnakeNaming{Prefix: "shop_", Plural: true}, "APIKey", "shop_api_keys"},
+	}
+	for _, tt := range tests {
+		if got := tt.naming.TableName(tt.typeName); got != tt.want {
+			t.Errorf("%+v.TableName(%q) = %q, want %q", tt.naming, tt.typeName, got, tt.want)
+		}
+	}
+}
+
+// LegacyUser has no tags, so its names come from Naming.
+type LegacyUser struct {
+	UserID   int64
+	FullName string
+}
+
+// namedRecord names its own table.
+type namedRecord struct {
+	Id int64 `orm:"id,PRIMARY_KEY"`
+}
+
+func (namedRecord) TableName() string { return "named" }
+
+// upperNaming is a NamingStrategy keeping Go names as they are.
+type upperNaming struct{}
+
+func (upperNaming) TableName(typeName string) string   { return typeName }
+func (upperNaming) ColumnName(fieldName string) string { return fieldName }
+
+func TestBindNaming(t *testing.T) {
+	defer func(n NamingStrategy) { Naming = n }(Naming)
+
+	tests := []struct {
+		naming  NamingStrategy
+		record  Record
+		table   string
+		columns []string
+	}{
+		{SnakeNaming{}, &LegacyUser{}, "legacy_user", []string{"user_id", "full_name"}},
+		{SnakeNaming{Prefix: "app_", Plural: true}, &LegacyUser{}, "app_legacy_users", []string{"user_id", "full_name"}},
+		{upperNaming{}, &LegacyUser{}, "LegacyUser", []string{"UserID", "FullName"}},
+		{SnakeNaming{Plural: true}, &namedRecord{}, "named", []string{"id"}},
+	}
+	for _, tt := range tests {
+		Naming = tt.naming
+		_, d := openFake(t, "mysql")
+		d.Bind("", tt.record)
+		if d.TableName() != tt.table || !reflect.DeepEqual(d.Columns(true), tt.columns) {
+			t.Errorf("%T: bound %s %v, want %s %v", tt.naming, d.TableName(), d.Columns(true), tt.table, tt.columns)
+		}
+	}
+}
@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"github.com/Masterminds/squirrel"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // TagOrm 'orm' is the main tag used for annotating Struct Records.
@@ -15,6 +18,17 @@ const TagComment = "desc"
 const TagDefault = "default"
 const TagColumnDefinition = "columnDefinition"
 
+// TagEnum 'enum' lists the values allowed in a column, comma separated.
+const TagEnum = "enum"
+
+// TagPrecision 'precision' and TagScale 'scale' set the decimal(p,s) type of
+// Decimal and float64 fields.
+const TagPrecision = "precision"
+const TagScale = "scale"
+
+// TagValidate 'validate' holds extra rules checked before writing a field, see Validate.
+const TagValidate = "validate"
+
 // Record describes a struct that can be stored.
 type Record interface{}
 
@@ -28,6 +42,8 @@ type field struct {
 	// comment = table column comment
 	// defaultVal = table column defaultVal
 	name, column, columnType, length, comment, defaultVal string
+	// index path of the struct field, through embedded structs
+	index []int
 	// Is a primary key
 	isKey bool
 	// Is an auto increment
@@ -36,6 +52,13 @@ type field struct {
 	isUnique bool
 	// Is a null key
 	isNull bool
+	// maxLength is the character limit set by the length tag or a char or
+	// varchar column definition, 0 if none
+	maxLength int
+	// enum lists the allowed values, from the enum tag or an enum(...) definition
+	enum []string
+	// rules from the validate tag
+	rules []rule
 }
 
 // A Recorder is responsible for managing the persistence of a Record.
@@ -63,9 +86,6 @@ type Recorder interface {
 	Haecceity
 	Saver
 	Describer
-
-	// This returns the column names used for the primary key.
-	//Name() []string
 }
 
 type Loader interface {
@@ -76,11 +96,20 @@ type Loader interface {
 	// 	SELECT * FROM bound_table WHERE id=? LIMIT 1
 	//
 	// And then mapping the result to the currently bound Record.
+	//
+	// ErrNotFound is returned when no row matches.
 	Load() error
 	// LoadWhere Load by a WHERE-like clause. See Squirrel's Where(pred, args)
+	// ErrNotFound is returned when no row matches.
 	LoadWhere(interface{}, ...interface{}) error
 }
 
+// Saver writes a Record.
+//
+// Insert and Update validate the Record first, see DbRecorder.Validate.
+//
+// Constraint violations are reported as a *ConstraintError matching
+// ErrDuplicateKey, ErrForeignKey or ErrCheckViolation.
 type Saver interface {
 	// Insert inserts the bound Record into the bound table.
 	Insert() error
@@ -104,6 +133,17 @@ type Saver interface {
 	DeleteByTx(tx *sql.Tx) error
 }
 
+// AffectedReporter is implemented by Savers that report the rows touched by
+// their writes, as DbRecorder does:
+//
+// 	if r, ok := d.(dorm.AffectedReporter); ok && r.RowsAffected() == 0 {
+// 		// nothing was updated
+// 	}
+type AffectedReporter interface {
+	// RowsAffected returns the number of rows touched by the last Update or Delete.
+	RowsAffected() int64
+}
+
 // Haecceity indicates whether a thing exists.
 //
 // Actually, it is responsible for testing whether a thing exists, and is
@@ -143,6 +183,27 @@ type Describer interface {
 	GetSchema() string
 }
 
+// Keyer is implemented by Recorders that know the order of the columns of
+// their primary key, as DbRecorder does. It gives Tuples their order; for
+// other Recorders, the columns of WhereIds are taken in alphabetical order.
+type Keyer interface {
+	// Key returns the columns of the primary key.
+	Key() []string
+}
+
+// keyOf returns the columns of the primary key of d.
+func keyOf(d Recorder) []string {
+	if k, ok := d.(Keyer); ok {
+		return k.Key()
+	}
+	var key = make([]string, 0)
+	for col := range d.WhereIds() {
+		key = append(key, col)
+	}
+	sort.Strings(key)
+	return key
+}
+
 // DbRecorder Implements the Recorder interface, and stores data in a DB.
 type DbRecorder struct {
 	builder *squirrel.StatementBuilderType
@@ -152,6 +213,15 @@ type DbRecorder struct {
 	key    []*field
 	record Record
 	flavor string
+	// rows affected by the last Update or Delete
+	affected       int64
+	verifyAffected bool
+	// generated accessors of the record, and the index of each field in them
+	accessor FieldAccessor
+	access   map[*field]int
+	// naming and hooks of the DB the recorder comes from, if any
+	naming NamingStrategy
+	hooks  *Hooks
 }
 
 func (s *DbRecorder) Interface() interface{} {
@@ -200,29 +270,128 @@ func (s *DbRecorder) Driver() string {
 	return s.flavor
 }
 
-// GetSchema returns the string name of the driver, only support mysql5.7 super.
+// namingStrategy returns the NamingStrategy of the recorder's DB, or Naming.
+func (s *DbRecorder) namingStrategy() NamingStrategy {
+	if s.naming != nil {
+		return s.naming
+	}
+	return Naming
+}
+
+// GetSchema returns the CREATE TABLE statement of the bound Record.
+//
+// The primary key is declared as a table constraint, so composite keys are
+// supported. Column comments and the table options are only written for
+// mysql, which also gets the AUTO_INCREMENT column first in the primary key,
+// or in an index of its own when it is not part of it. On sqlite a single
+// AUTO_INCREMENT key becomes an INTEGER PRIMARY KEY, and on postgres a
+// bigserial.
 func (s *DbRecorder) GetSchema() string {
-	var col = make([]string, 0)
+	var rowid = s.isSqlite() && len(s.key) == 1 && s.key[0].isAuto
+
+	var col = make([]string, 0, len(s.fields)+1)
+	for _, f := range s.fields {
+		col = append(col, s.columnSchema(f, rowid))
+	}
+	if len(s.key) > 0 && !rowid {
+		col = append(col, fmt.Sprintf("PRIMARY KEY (%v)", strings.Join(quoteAll(s.flavor, s.schemaKey()), ", ")))
+	}
+	if auto := s.AutoIncrement(); auto != "" && s.isMysql() && !s.isKey(auto) {
+		// mysql only takes an AUTO_INCREMENT column that starts an index
+		col = append(col, fmt.Sprintf("KEY (%v)", Quote(s.flavor, auto)))
+	}
+
+	var schema = fmt.Sprintf("create table IF NOT EXISTS %v (%v)", Quote(s.flavor, s.table), strings.Join(col, ",\n"))
+	if s.isMysql() {
+		schema += " ENGINE = InnoDB DEFAULT CHARSET = utf8mb4"
+	}
+	return schema + ";"
+}
+
+// schemaKey returns the primary key columns in the order GetSchema declares
+// them: on mysql, an AUTO_INCREMENT column must come first.
+func (s *DbRecorder) schemaKey() []string {
+	var cols = s.Key()
+	if !s.isMysql() {
+		return cols
+	}
+	for i, c := range cols {
+		if c == s.AutoIncrement() {
+			copy(cols[1:i+1], cols[:i])
+			cols[0] = c
+		}
+	}
+	return cols
+}
+
+// isKey reports whether column is part of the primary key.
+func (s *DbRecorder) isKey(column string) bool {
+	for _, f := range s.key {
+		if f.column == column {
+			return true
+		}
+	}
+	return false
+}
+
+// SchemaDiff returns the statements that bring a live table, whose columns are
+// given by InspectColumns, in line with the bound Record, and those undoing
+// them. A table without columns is created by GetSchema and dropped; otherwise
+// each column missing from it is added, and dropped again. Columns of other
+// types, and columns the Record does not map, are left alone.
+func (s *DbRecorder) SchemaDiff(live []ColumnInfo) (up, down []string) {
+	var table = Quote(s.flavor, s.table)
+	if len(live) == 0 {
+		return []string{s.GetSchema()}, []string{fmt.Sprintf("drop table IF EXISTS %v;", table)}
+	}
+
+	var have = make(map[string]bool, len(live))
+	for _, c := range live {
+		have[strings.ToLower(c.Name)] = true
+	}
 	for _, f := range s.fields {
-		if f.column == "" {
+		if have[strings.ToLower(f.column)] {
 			continue
 		}
-		if strings.ToLower(f.name) == "id" || (f.isKey && f.isAuto) {
-			var sq = fmt.Sprintf("id bigint unique auto_increment primary key")
-			col = append(col, sq)
-		} else {
-			var uniqueVal = ""
-			if f.isUnique {
-				uniqueVal = " UNIQUE"
-			}
-			var sq = fmt.Sprintf("%v %v%v comment '%v'", f.column, f.columnType, uniqueVal, f.comment)
-			col = append(col, sq)
+		up = append(up, fmt.Sprintf("alter table %v add column %v;", table, s.columnSchema(f, false)))
+		down = append(down, fmt.Sprintf("alter table %v drop column %v;", table, Quote(s.flavor, f.column)))
+	}
+	for i, j := 0, len(down)-1; i < j; i, j = i+1, j-1 {
+		down[i], down[j] = down[j], down[i]
+	}
+	return up, down
+}
+
+// columnSchema returns the definition of the column of f. rowid makes an
+// AUTO_INCREMENT field the INTEGER PRIMARY KEY of a sqlite table.
+func (s *DbRecorder) columnSchema(f *field, rowid bool) string {
+	var sq string
+	switch {
+	case f.isAuto && rowid:
+		sq = fmt.Sprintf("%v integer primary key autoincrement", Quote(s.flavor, f.column))
+	case f.isAuto && s.flavor == "postgres":
+		sq = fmt.Sprintf("%v bigserial", Quote(s.flavor, f.column))
+	case f.isAuto:
+		sq = fmt.Sprintf("%v bigint auto_increment", Quote(s.flavor, f.column))
+	default:
+		var uniqueVal = ""
+		if f.isUnique {
+			uniqueVal = " UNIQUE"
 		}
+		sq = fmt.Sprintf("%v %v%v", Quote(s.flavor, f.column), f.columnType, uniqueVal)
 	}
+	if s.isMysql() {
+		sq += fmt.Sprintf(" comment '%v'", strings.ReplaceAll(f.comment, "'", "''"))
+	}
+	return sq
+}
+
+func (s *DbRecorder) isSqlite() bool {
+	return s.flavor == "sqlite3" || s.flavor == "sqlite"
+}
 
-	var engineCharset = "ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;"
-	var schema = fmt.Sprintf("create table IF NOT EXISTS %v (%v) %v", s.TableName(), strings.Join(col, ",\n"), engineCharset)
-	return schema
+func (s *DbRecorder) isMysql() bool {
+	return s.flavor != "postgres" && !s.isSqlite()
 }
 
 // Bind binds a DbRecorder to a Record.
@@ -231,22 +400,51 @@ func (s *DbRecorder) GetSchema() string {
 // that the recorder will track all changes to the Record.
 //
 // The table name tells the recorder which database table to link this record
-// to. All storage operations will use that table.
+// to. All storage operations will use that table. When it is empty, the table
+// is given by the TableName method of the Record if it has one, or else by
+// Naming from the struct type (see NamingStrategy).
 func (s *DbRecorder) Bind(tableName string, ar Record) Recorder {
 
 	// "To be is to be the value of a bound variable." - W. O. Quine
 
 	// Get the table name
+	if tableName == "" {
+		tableName = tableOf(ar, s.namingStrategy())
+	}
 	s.table = tableName
 
 	// Get the fields
 	s.scanFields(ar)
 
 	s.record = ar
+	s.bindAccessor()
 
 	return Recorder(s)
 }
 
+// AutoIncrement returns the AUTO_INCREMENT column of the bound Record, or ""
+// if it has none.
+func (s *DbRecorder) AutoIncrement() string {
+	for _, f := range s.fields {
+		if f.isAuto {
+			return f.column
+		}
+	}
+	return ""
+}
+
+// FieldByColumn returns the settable field of the bound Record mapped to
+// column, allocating the embedded pointers it is promoted through. The zero
+// Value is returned for an unknown column.
+func (s *DbRecorder) FieldByColumn(column string) reflect.Value {
+	for _, f := range s.fields {
+		if f.column == column {
+			return fieldByIndex(reflect.Indirect(reflect.ValueOf(s.record)), f.index, true)
+		}
+	}
+	return reflect.Value{}
+}
+
 // Key gets the string names of the fields used as primary key.
 func (s *DbRecorder) Key() []string {
 	key := make([]string, len(s.key))
@@ -269,10 +467,10 @@ func (s *DbRecorder) Load() error {
 	whereParts := s.WhereIds()
 	dest := s.FieldReferences(false)
 
-	q := s.builder.Select(s.colList(false, false)...).From(s.table).Where(whereParts)
-	err := q.QueryRow().Scan(dest...)
+	q := s.builder.Select(quoteAll(s.flavor, s.colList(false, false))...).From(Quote(s.flavor, s.table)).Where(quoteEq(s.flavor, whereParts))
+	err := queryRow(q).Scan(dest...)
 
-	return err
+	return TranslateError(s.flavor, err)
 }
 
 // LoadWhere loads an object based on a WHERE clause.
@@ -288,10 +486,10 @@ func (s *DbRecorder) Load() error {
 func (s *DbRecorder) LoadWhere(pred interface{}, args ...interface{}) error {
 	dest := s.FieldReferences(true)
 
-	q := s.builder.Select(s.colList(true, true)...).From(s.table).Where(pred, args...)
-	err := q.QueryRow().Scan(dest...)
+	q := s.builder.Select(quoteAll(s.flavor, s.colList(true, true))...).From(Quote(s.flavor, s.table)).Where(pred, args...)
+	err := queryRow(q).Scan(dest...)
 
-	return err
+	return TranslateError(s.flavor, err)
 }
 
 // Exists returns `true` if and only if there is at least one record that matches the primary keys for this Record.
@@ -302,8 +500,8 @@ func (s *DbRecorder) Exists() (bool, error) {
 	has := false
 	whereParts := s.WhereIds()
 
-	q := s.builder.Select("COUNT(*) > 0").From(s.table).Where(whereParts)
-	err := q.QueryRow().Scan(&has)
+	q := s.builder.Select("COUNT(*) > 0").From(Quote(s.flavor, s.table)).Where(quoteEq(s.flavor, whereParts))
+	err := queryRow(q).Scan(&has)
 
 	return has, err
 }
@@ -315,8 +513,8 @@ func (s *DbRecorder) Exists() (bool, error) {
 func (s *DbRecorder) ExistsWhere(pred interface{}, args ...interface{}) (bool, error) {
 	has := false
 
-	q := s.builder.Select("COUNT(*) > 0").From(s.table).Where(pred, args...)
-	err := q.QueryRow().Scan(&has)
+	q := s.builder.Select("COUNT(*) > 0").From(Quote(s.flavor, s.table)).Where(pred, args...)
+	err := queryRow(q).Scan(&has)
 
 	return has, err
 }
@@ -324,18 +522,21 @@ func (s *DbRecorder) ExistsWhere(pred interface{}, args ...interface{}) (bool, e
 // Delete deletes the record from the underlying table.
 //
 // The fields on the present record will remain set, but not saved in the database.
+// RowsAffected reports how many rows were removed.
 func (s *DbRecorder) Delete() error {
-	wheres := s.WhereIds()
-	q := s.builder.Delete(s.table).Where(wheres)
-	_, err := q.Exec()
-	return err
+	return s.hooks.around(s, hookDelete, func() error {
+		wheres := s.WhereIds()
+		q := s.builder.Delete(Quote(s.flavor, s.table)).Where(quoteEq(s.flavor, wheres))
+		return s.affectedRows(q.Exec())
+	})
 }
 
 func (s *DbRecorder) DeleteByTx(tx *sql.Tx) error {
-	wheres := s.WhereIds()
-	q := squirrel.Delete(s.table).RunWith(tx).Where(wheres)
-	_, err := q.Exec()
-	return err
+	return s.hooks.around(s, hookDelete, func() error {
+		wheres := s.WhereIds()
+		q := s.builder.Delete(Quote(s.flavor, s.table)).RunWith(tx).Where(quoteEq(s.flavor, wheres))
+		return s.affectedRows(q.Exec())
+	})
 }
 
 // Insert puts a new record into the database.
@@ -343,46 +544,37 @@ func (s *DbRecorder) DeleteByTx(tx *sql.Tx) error {
 // This operation is particularly sensitive to DB differences in cases where AUTO_INCREMENT is set
 // on a member of the Record.
 func (s *DbRecorder) Insert() error {
-	switch s.flavor {
-	case "postgres":
-		return s.insertPg()
-	default:
-		return s.insertStd()
-	}
+	return s.hooks.around(s, hookInsert, func() error {
+		if err := s.Validate(); err != nil {
+			return err
+		}
+		switch s.flavor {
+		case "postgres":
+			return s.insertPg(nil)
+		default:
+			return s.insertStd()
+		}
+	})
 }
 
 // InsertByTx insert by transaction
 func (s *DbRecorder) InsertByTx(tx *sql.Tx) error {
-	var placeholderFormat squirrel.PlaceholderFormat = squirrel.Question
-
-	if s.flavor == "postgres" {
-		placeholderFormat = squirrel.Dollar
-	}
-
-	columns, values := s.colValLists(true, false)
-
-	ret, err := squirrel.Insert(s.table).Columns(columns...).Values(values...).RunWith(tx).PlaceholderFormat(placeholderFormat).Exec()
-	if err != nil {
-		return err
-	}
-
-	for _, f := range s.fields {
-		if f.isAuto {
-			ar := reflect.Indirect(reflect.ValueOf(s.record))
-			field := ar.FieldByName(f.name)
+	return s.hooks.around(s, hookInsert, func() error {
+		if err := s.Validate(); err != nil {
+			return err
+		}
+		if s.flavor == "postgres" {
+			return s.insertPg(tx)
+		}
 
-			id, err := ret.LastInsertId()
-			if err != nil {
-				return fmt.Errorf("Could not get last insert Id. Did you set the db flavor? %s", err)
-			}
+		columns, values := s.colValLists(true, false)
 
-			if !field.CanSet() {
-				return fmt.Errorf("Could not set %s to returned value", f.name)
-			}
-			field.SetInt(id)
+		ret, err := s.builder.Insert(Quote(s.flavor, s.table)).Columns(quoteAll(s.flavor, columns)...).Values(values...).RunWith(tx).Exec()
+		if err != nil {
+			return TranslateError(s.flavor, err)
 		}
-	}
-	return nil
+		return s.setAutoId(ret)
+	})
 }
 
 // Insert and assume that LastInsertId() returns something.
@@ -390,48 +582,69 @@ func (s *DbRecorder) insertStd() error {
 
 	cols, vals := s.colValLists(true, false)
 
-	q := s.builder.Insert(s.table).Columns(cols...).Values(vals...)
+	q := s.builder.Insert(Quote(s.flavor, s.table)).Columns(quoteAll(s.flavor, cols)...).Values(vals...)
 
 	ret, err := q.Exec()
 	if err != nil {
-		return err
+		return TranslateError(s.flavor, err)
 	}
+	return s.setAutoId(ret)
+}
 
+// setAutoId stores the id generated by an INSERT into the AUTO_INCREMENT
+// field, if any. A table has at most one such column.
+func (s *DbRecorder) setAutoId(ret sql.Result) error {
 	for _, f := range s.fields {
-		if f.isAuto {
-			ar := reflect.Indirect(reflect.ValueOf(s.record))
-			field := ar.FieldByName(f.name)
+		if !f.isAuto {
+			continue
+		}
 
-			id, err := ret.LastInsertId()
-			if err != nil {
-				return fmt.Errorf("Could not get last insert Id. Did you set the db flavor? %s", err)
-			}
+		ar := reflect.Indirect(reflect.ValueOf(s.record))
+		field := fieldByIndex(ar, f.index, true)
 
-			if !field.CanSet() {
-				return fmt.Errorf("Could not set %s to returned value", f.name)
-			}
+		id, err := ret.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("Could not get last insert Id. Did you set the db flavor? %s", err)
+		}
+
+		switch {
+		case !field.CanSet():
+			return fmt.Errorf("Could not set %s to returned value", f.name)
+		case field.CanInt():
 			field.SetInt(id)
+		case field.CanUint():
+			field.SetUint(uint64(id))
+		default:
+			return fmt.Errorf("Could not set %s to returned value", f.name)
 		}
+		return nil
 	}
-
-	return err
+	return nil
 }
 
 // insertPg runs a postgres-specific INSERT. Unlike the default (MySQL) driver,
 // this actually refreshes ALL of the fields on the Record object. We do this
 // because it is trivially easy in Postgres.
-func (s *DbRecorder) insertPg() error {
+//
+// The INSERT runs in tx when it is not nil.
+func (s *DbRecorder) insertPg(tx *sql.Tx) error {
 	cols, vals := s.colValLists(true, false)
 	dest := s.FieldReferences(true)
-	q := s.builder.Insert(s.table).Columns(cols...).Values(vals...).
-		Suffix("RETURNING " + strings.Join(s.colList(true, false), ","))
+	q := s.builder.Insert(Quote(s.flavor, s.table)).Columns(quoteAll(s.flavor, cols)...).Values(vals...).
+		Suffix("RETURNING " + strings.Join(quoteAll(s.flavor, s.colList(true, false)), ","))
 
 	_sql, vals, err := q.ToSql()
 	if err != nil {
 		return err
 	}
 
-	return s.db.QueryRow(_sql, vals...).Scan(dest...)
+	var row squirrel.RowScanner
+	if tx != nil {
+		row = tx.QueryRow(_sql, vals...)
+	} else {
+		row = s.db.QueryRow(_sql, vals...)
+	}
+	return TranslateError(s.flavor, row.Scan(dest...))
 }
 
 // Update updates the values on an existing entry.
@@ -440,20 +653,65 @@ func (s *DbRecorder) insertPg() error {
 // database. Essentially, it runs `UPDATE table SET names=values WHERE id=?`
 //
 // If no entry is found, update will NOT create (INSERT) a new record.
+// RowsAffected reports how many rows were changed; see VerifyAffected to turn
+// a miss into ErrNoRowsAffected.
 func (s *DbRecorder) Update() error {
-	whereParts := s.WhereIds()
-	updates := s.updateFields()
-	q := s.builder.Update(s.table).SetMap(updates).Where(whereParts)
+	return s.hooks.around(s, hookUpdate, func() error {
+		if err := s.Validate(); err != nil {
+			return err
+		}
+		whereParts := s.WhereIds()
+		updates := s.updateFields()
+		q := s.builder.Update(Quote(s.flavor, s.table)).SetMap(quoteKeys(s.flavor, updates)).Where(quoteEq(s.flavor, whereParts))
 
-	_, err := q.Exec()
-	return err
+		return s.affectedRows(q.Exec())
+	})
 }
 
 func (s *DbRecorder) UpdateByTx(tx *sql.Tx) error {
-	whereParts := s.WhereIds()
-	updates := s.updateFields()
-	_, err := squirrel.Update(s.table).SetMap(updates).Where(whereParts).RunWith(tx).Exec()
-	return err
+	return s.hooks.around(s, hookUpdate, func() error {
+		if err := s.Validate(); err != nil {
+			return err
+		}
+		whereParts := s.WhereIds()
+		updates := s.updateFields()
+		q := s.builder.Update(Quote(s.flavor, s.table)).SetMap(quoteKeys(s.flavor, updates)).Where(quoteEq(s.flavor, whereParts))
+		return s.affectedRows(q.RunWith(tx).Exec())
+	})
+}
+
+// RowsAffected returns the number of rows touched by the last Update or Delete.
+func (s *DbRecorder) RowsAffected() int64 {
+	return s.affected
+}
+
+// VerifyAffected makes Update and Delete (and their ByTx variants) return
+// ErrNoRowsAffected when the primary key matched no row.
+//
+// This is off by default: MySQL reports 0 affected rows for an UPDATE that
+// does not change any value, unless the connection sets clientFoundRows.
+func (s *DbRecorder) VerifyAffected(verify bool) *DbRecorder {
+	s.verifyAffected = verify
+	return s
+}
+
+// affectedRows records the row count of an Update or Delete result.
+func (s *DbRecorder) affectedRows(ret sql.Result, err error) error {
+	s.affected = 0
+	if err != nil {
+		return TranslateError(s.flavor, err)
+	}
+
+	n, err := ret.RowsAffected()
+	if err != nil {
+		return err
+	}
+	s.affected = n
+
+	if s.verifyAffected && n == 0 {
+		return ErrNoRowsAffected
+	}
+	return nil
 }
 
 // Columns returns the names of the columns on this table.
@@ -471,6 +729,12 @@ func (s *DbRecorder) Columns(includeKeys bool) []string {
 func (s *DbRecorder) colList(withKeys bool, omitNil bool) []string {
 	names := make([]string, 0, len(s.fields))
 
+	// Generated accessors scan pointers through a pointer to the pointer, so
+	// a nil pointer still has a destination.
+	if s.accessor != nil {
+		omitNil = false
+	}
+
 	var ar reflect.Value
 	if omitNil {
 		ar = reflect.Indirect(reflect.ValueOf(s.record))
@@ -481,8 +745,8 @@ func (s *DbRecorder) colList(withKeys bool, omitNil bool) []string {
 			continue
 		}
 		if omitNil {
-			f := ar.FieldByName(field.name)
-			if f.Kind() == reflect.Ptr && f.IsNil() {
+			f := fieldByIndex(ar, field.index, false)
+			if !f.IsValid() || (f.Kind() == reflect.Ptr && f.IsNil()) {
 				continue
 			}
 		}
@@ -505,13 +769,23 @@ func (s *DbRecorder) colList(withKeys bool, omitNil bool) []string {
 func (s *DbRecorder) FieldReferences(withKeys bool) []interface{} {
 	refs := make([]interface{}, 0)
 
+	if s.accessor != nil {
+		all := s.accessor.DormFieldRefs()
+		for _, field := range s.fields {
+			if withKeys || !field.isKey {
+				refs = append(refs, all[s.access[field]])
+			}
+		}
+		return refs
+	}
+
 	ar := reflect.Indirect(reflect.ValueOf(s.record))
 	for _, field := range s.fields {
 		if !withKeys && field.isKey {
 			continue
 		}
 
-		fv := ar.FieldByName(field.name)
+		fv := fieldByIndex(ar, field.index, true)
 		var ref reflect.Value
 		if fv.Kind() != reflect.Ptr {
 			// we want the address of field
@@ -537,6 +811,11 @@ func (s *DbRecorder) FieldReferences(withKeys bool) []interface{} {
 func (s *DbRecorder) colValLists(withKeys, withAutos bool) (columns []string, values []interface{}) {
 	ar := reflect.Indirect(reflect.ValueOf(s.record))
 
+	var vals []interface{}
+	if s.accessor != nil {
+		vals = s.accessor.DormValues()
+	}
+
 	for _, field := range s.fields {
 
 		switch {
@@ -546,8 +825,21 @@ func (s *DbRecorder) colValLists(withKeys, withAutos bool) (columns []string, va
 			continue
 		}
 
+		if vals != nil {
+			// a nil value is a nil pointer: nothing to store
+			if v := vals[s.access[field]]; v != nil {
+				values = append(values, v)
+				columns = append(columns, field.column)
+			}
+			continue
+		}
+
 		// Get the value of the field we are going to store.
-		f := ar.FieldByName(field.name)
+		f := fieldByIndex(ar, field.index, false)
+		if !f.IsValid() {
+			// behind a nil embedded pointer: nothing to store
+			continue
+		}
 		var v reflect.Value
 		if f.Kind() == reflect.Ptr {
 			if f.IsNil() {
@@ -584,113 +876,241 @@ func (s *DbRecorder) updateFields() map[string]interface{} {
 func (s *DbRecorder) WhereIds() map[string]interface{} {
 	clause := make(map[string]interface{}, len(s.key))
 
+	if s.accessor != nil {
+		vals := s.accessor.DormValues()
+		for _, f := range s.key {
+			clause[f.column] = vals[s.access[f]]
+		}
+		return clause
+	}
+
 	ar := reflect.Indirect(reflect.ValueOf(s.record))
 
 	for _, f := range s.key {
-		clause[f.column] = ar.FieldByName(f.name).Interface()
+		if fv := fieldByIndex(ar, f.index, false); fv.IsValid() {
+			clause[f.column] = fv.Interface()
+		} else {
+			clause[f.column] = nil
+		}
 	}
 
 	return clause
 }
 
 // scanFields extracts the tags from all the fields on a struct.
+//
+// The fields of anonymous structs, and of pointers to them, are promoted
+// recursively; so are those of a named struct field tagged
+// `orm:"embedded"`, with an optional column prefix:
+//
+// 	type Order struct {
+// 		dorm.Model
+// 		Billing  Address `orm:"embedded,prefix:billing_"`
+// 		Shipping Address `orm:"embedded,prefix:shipping_"`
+// 		Internal string  `orm:"-"`
+// 	}
+//
+// Types that scan themselves (sql.Scanner, driver.Valuer) and time.Time are
+// single columns. Unexported fields, fields tagged `orm:"-"`, and interface,
+// func and chan fields are skipped. When two fields map to the same column, the
+// shallower one wins, as with Go field promotion.
 func (s *DbRecorder) scanFields(ar Record) {
-	v := reflect.Indirect(reflect.ValueOf(ar))
-	t := v.Type()
-	count := t.NumField()
-	s.fields = make([]*field, 0)
-	for i := 0; i < count; i++ {
-		f := t.Field(i)
-		if f.Type.Kind() == reflect.Struct && f.Type.Name() != "Time" {
-			keys, fields := s.getFields(f.Type)
+	t := reflect.Indirect(reflect.ValueOf(ar)).Type()
 
-			s.key = append(s.key, keys...)
-			s.fields = append(s.fields, fields...)
-		}
-
-		// Skip fields with no tag.
-		var typename = f.Type.Name()
-		if typename == "Model" || typename == "Recorder" || typename == "NullTime" {
-			continue
-		}
-
-		field := s.getField(f)
-		if field.isKey {
-			s.key = append(s.key, field)
-		}
-		s.fields = append(s.fields, field)
-	}
-}
-
-func (s *DbRecorder) getFields(t reflect.Type) (keys []*field, fields []*field) {
-	count := t.NumField()
-	for i := 0; i < count; i++ {
-		f := t.Field(i)
-		// Skip fields with no tag.
-		var typename = f.Type.Name()
-		if typename == "Model" || typename == "Recorder" || typename == "NullTime" {
-			continue
+	s.fields = make([]*field, 0)
+	var depth = make(map[string]int)
+	var pos = make(map[string]int)
+	walkFields(t, s.namingStrategy(), func(sf structField) {
+		f := s.getField(sf)
+		if i, ok := pos[f.column]; ok {
+			if sf.depth < depth[f.column] {
+				s.fields[i], depth[f.column] = f, sf.depth
+			}
+			return
 		}
+		pos[f.column], depth[f.column] = len(s.fields), sf.depth
+		s.fields = append(s.fields, f)
+	})
 
-		field := s.getField(f)
-		if field.isKey {
-			keys = append(keys, field)
+	s.key = make([]*field, 0)
+	for _, f := range s.fields {
+		if f.isKey {
+			s.key = append(s.key, f)
 		}
-		fields = append(fields, field)
 	}
-	return keys, fields
 }
 
-func (s *DbRecorder) getField(f reflect.StructField) *field {
+func (s *DbRecorder) getField(sf structField) *field {
+	f := sf.StructField
 	field := new(field)
-
-	ormTag := f.Tag.Get(TagOrm)
-	ormParts := s.parseTag(f.Name, ormTag)
-	if len(ormParts) == 0 {
-		field.column = s.camel2Case(f.Name)
-	} else {
-		field.column = ormParts[0]
-		for _, part := range ormParts[1:] {
-			part = strings.TrimSpace(part)
-			switch part {
-			case "PRIMARY_KEY", "PRIMARY KEY":
-				field.isKey = true
-			case "AUTO_INCREMENT", "SERIAL", "AUTO INCREMENT":
-				field.isAuto = true
-			case "UNIQUE":
-				field.isUnique = true
-			case "NULL":
-				field.isNull = true
-			}
+	field.column = sf.column
+	field.index = sf.index
+	for _, part := range sf.options {
+		switch part {
+		case "PRIMARY_KEY", "PRIMARY KEY":
+			field.isKey = true
+		case "AUTO_INCREMENT", "SERIAL", "AUTO INCREMENT":
+			field.isAuto = true
+		case "UNIQUE":
+			field.isUnique = true
+		case "NULL":
+			field.isNull = true
 		}
 	}
 
-	field.name = f.Name
+	field.name = sf.name
+	field.length = f.Tag.Get(TagLength)
+	field.defaultVal = f.Tag.Get(TagDefault)
 	var columnDefinition = f.Tag.Get(TagColumnDefinition)
 	if columnDefinition != "" {
 		field.columnType = columnDefinition
 	} else {
-		var length = f.Tag.Get(TagLength)
-		var defaultVal = f.Tag.Get(TagDefault)
-		var columnType = s.parseType(f.Type, length, defaultVal, field.isNull)
+		var columnType = s.parseType(f.Type, field.length, field.defaultVal, decimalColumn(f.Tag), field.isNull)
 		field.columnType = columnType
 	}
 	field.comment = f.Tag.Get(TagComment)
+	// only a declared length limits the value: an untagged string defaults to
+	// varchar(255), but may well be stored in a TEXT column
+	if columnDefinition != "" {
+		field.maxLength = columnLength(columnDefinition)
+	} else if n, err := strconv.Atoi(field.length); err == nil {
+		field.maxLength = n
+	}
+	field.enum = parseEnum(f.Tag.Get(TagEnum), columnDefinition)
+	field.rules = parseRules(f.Tag.Get(TagValidate))
 
 	return field
 }
 
-// parseTag parses the contents of a stbl tag.
-func (s *DbRecorder) parseTag(fieldName, tag string) []string {
-	parts := strings.Split(tag, ",")
-	if len(parts) == 0 {
-		return []string{fieldName}
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	nullTimeType = reflect.TypeOf(sql.NullTime{})
+)
+
+// isColumnType reports whether a struct type is stored in a single column
+// rather than having its fields promoted.
+func isColumnType(t reflect.Type) bool {
+	return t == timeType || reflect.PtrTo(t).Implements(scannerType) || reflect.PtrTo(t).Implements(valuerType)
+}
+
+// structField is a struct field mapped to a column by walkFields.
+type structField struct {
+	reflect.StructField
+	// index path from the outer struct, name the Go selector of the field
+	index []int
+	name  string
+	// column name, prefix included, and the other parts of the orm tag
+	column  string
+	options []string
+	// depth of embedding
+	depth int
+}
+
+// walkFields calls fn for every field of struct type t that maps to a column,
+// in field order, following the rules of scanFields. Fields without a column
+// in their tag are named by naming. Fields with the same column are all
+// reported; resolving them is up to fn.
+func walkFields(t reflect.Type, naming NamingStrategy, fn func(structField)) {
+	var walk func(t reflect.Type, index []int, name, prefix string, depth int, seen map[reflect.Type]bool)
+	walk = func(t reflect.Type, index []int, name, prefix string, depth int, seen map[reflect.Type]bool) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			parts := strings.Split(f.Tag.Get(TagOrm), ",")
+			for j := range parts {
+				parts[j] = strings.TrimSpace(parts[j])
+			}
+			if parts[0] == "-" {
+				continue
+			}
+			path := append(append([]int{}, index...), i)
+
+			ft, ptr := f.Type, f.Type.Kind() == reflect.Ptr
+			if ptr {
+				ft = ft.Elem()
+			}
+			if (f.Anonymous || parts[0] == "embedded") && ft.Kind() == reflect.Struct && !isColumnType(ft) {
+				// nil pointers behind unexported fields cannot be allocated
+				if (ptr && f.PkgPath != "") || seen[ft] {
+					continue
+				}
+				var sub, subPrefix = name, prefix
+				if !f.Anonymous {
+					sub = name + f.Name + "."
+				}
+				for _, part := range parts[1:] {
+					if strings.HasPrefix(part, "prefix:") {
+						subPrefix += strings.TrimPrefix(part, "prefix:")
+					}
+				}
+				seen[ft] = true
+				walk(ft, path, sub, subPrefix, depth+1, seen)
+				delete(seen, ft)
+				continue
+			}
+
+			if f.PkgPath != "" {
+				continue
+			}
+			switch f.Type.Kind() {
+			case reflect.Interface, reflect.Func, reflect.Chan:
+				continue
+			}
+
+			var column = parts[0]
+			if column == "" || column == "embedded" {
+				column = naming.ColumnName(f.Name)
+			}
+			fn(structField{StructField: f, index: path, name: name + f.Name,
+				column: prefix + column, options: parts[1:], depth: depth})
+		}
+	}
+	walk(t, nil, "", "", 0, map[reflect.Type]bool{t: true})
+}
+
+// fieldByIndex returns the field of struct v at index. Nil embedded pointers on
+// the way are allocated if alloc is set; otherwise the zero Value is returned.
+func fieldByIndex(v reflect.Value, index []int, alloc bool) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !alloc {
+					return reflect.Value{}
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
 	}
-	return parts
+	return v
 }
 
 // parseType parses the contents of type .
-func (s *DbRecorder) parseType(p reflect.Type, length string, defaultVal string, isNull bool) string {
+func (s *DbRecorder) parseType(p reflect.Type, length string, defaultVal string, decimal string, isNull bool) string {
+	switch p {
+	case nullTimeType:
+		return s.datetimeType() + " null"
+	case decimalType:
+		return decimal + " default 0"
+	case nullDecimalType:
+		if isNull {
+			return decimal + " null"
+		}
+		return decimal
+	case dateType:
+		if isNull {
+			return "date null"
+		}
+		return "date"
+	case timeOfDayType:
+		if isNull {
+			return "time null"
+		}
+		return "time"
+	}
+	if p.Implements(jsonColumnType) {
+		return s.jsonType()
+	}
 	switch p.Kind() {
 	case reflect.Int:
 		return "int default 0"
@@ -709,9 +1129,11 @@ func (s *DbRecorder) parseType(p reflect.Type, length string, defaultVal string,
 	case reflect.Int64:
 		return "bigint default 0"
 	case reflect.Float64:
-		return "decimal(12,4) default 0.00"
+		return decimal + " default 0.00"
 	case reflect.String:
-		if defaultVal == "" {
+		if defaultVal == "" && isNull {
+			defaultVal = "null"
+		} else if defaultVal == "" {
 			defaultVal = "default '' not null"
 		}
 		if length == "" {
@@ -719,24 +1141,27 @@ func (s *DbRecorder) parseType(p reflect.Type, length string, defaultVal string,
 		}
 		return fmt.Sprintf("varchar(%v) %v", length, defaultVal)
 	case reflect.Slice:
-		return "json"
+		return s.jsonType()
 	case reflect.Array:
-		return "json"
+		return s.jsonType()
 	case reflect.Bool:
+		if isNull {
+			return "boolean null"
+		}
 		return "boolean default false not null"
 	case reflect.Struct:
 		var typename = p.Name()
 		if typename == "Time" {
 			if isNull {
-				return "datetime null"
+				return s.datetimeType() + " null"
 			}
-			return "datetime default now()"
+			return s.datetimeType() + " default " + s.now()
 		}
 		if typename == "Strings" {
-			return "json"
+			return s.jsonType()
 		}
 		if typename == "Int64s" {
-			return "json"
+			return s.jsonType()
 		}
 		return "varchar(255)"
 	default:
@@ -744,20 +1169,46 @@ func (s *DbRecorder) parseType(p reflect.Type, length string, defaultVal string,
 	}
 }
 
-//camel2Case 驼峰转蛇形命名
-func (s *DbRecorder) camel2Case(name string) string {
-	data := make([]byte, 0, len(name)*2)
-	notCase := false
-	num := len(name)
-	for i := 0; i < num; i++ {
-		d := name[i]
-		if i > 0 && d >= 'A' && d <= 'Z' && notCase {
-			data = append(data, '_')
-		}
-		if d != '_' {
-			notCase = true
-		}
-		data = append(data, d)
+// datetimeType returns the type of date and time columns: postgres has no
+// datetime.
+func (s *DbRecorder) datetimeType() string {
+	if s.flavor == "postgres" {
+		return "timestamp"
 	}
-	return strings.ToLower(string(data[:]))
-}
\ No newline at end of file
+	return "datetime"
+}
+
+// now returns the default of a column set to the time of the insert: sqlite
+// only accepts CURRENT_TIMESTAMP, or an expression in parentheses.
+func (s *DbRecorder) now() string {
+	if s.isSqlite() {
+		return "CURRENT_TIMESTAMP"
+	}
+	return "now()"
+}
+
+// jsonType returns the type of json columns.
+func (s *DbRecorder) jsonType() string {
+	if s.flavor == "postgres" {
+		return "jsonb"
+	}
+	return "json"
+}
+
+// queryRow is q.QueryRow, except that a query failing to build is not sent to
+// the database: squirrel would run the empty SQL and only report the error on Scan.
+func queryRow(q squirrel.SelectBuilder) squirrel.RowScanner {
+	if _, _, err := q.ToSql(); err != nil {
+		return errRow{err}
+	}
+	return q.QueryRow()
+}
+
+// errRow is a RowScanner that fails with err.
+type errRow struct {
+	err error
+}
+
+func (r errRow) Scan(...interface{}) error {
+	return r.err
+}
@@ -0,0 +1,121 @@
+package dorm
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type account struct {
+	Id   int64  `orm:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Name string `orm:"name"`
+}
+
+func TestRowsAffected(t *testing.T) {
+	failed := errors.New("gone away")
+	tests := []struct {
+		name     string
+		write    func(d *DbRecorder, f *fakeDB) error
+		affected int64
+		verify   bool
+		err      error
+		want     error
+	}{
+		{name: "update", write: updateRecord, affected: 1},
+		{name: "update of no row", write: updateRecord, affected: 0},
+		{name: "update of no row, verified", write: updateRecord, affected: 0, verify: true, want: ErrNoRowsAffected},
+		{name: "delete of two rows, verified", write: deleteRecord, affected: 2, verify: true},
+		{name: "delete of no row, verified", write: deleteRecord, affected: 0, verify: true, want: ErrNoRowsAffected},
+		{name: "failed update", write: updateRecord, affected: 1, err: failed, want: failed},
+		{
+			name: "update in a transaction", affected: 0, verify: true, want: ErrNoRowsAffected,
+			write: func(d *DbRecorder, f *fakeDB) error {
+				tx, err := f.db.Begin()
+				if err != nil {
+					return err
+				}
+				defer tx.Rollback()
+				return d.UpdateByTx(tx)
+			},
+		},
+		{
+			name: "delete in a transaction", affected: 3,
+			write: func(d *DbRecorder, f *fakeDB) error {
+				tx, err := f.db.Begin()
+				if err != nil {
+					return err
+				}
+				defer tx.Rollback()
+				return d.DeleteByTx(tx)
+			},
+		},
+	}
+	for _, tt := range tests {
+		f, d := openFake(t, "mysql")
+		f.affected, f.err = tt.affected, tt.err
+		d.Bind("accounts", &account{Id: 7, Name: "bo"})
+		d.VerifyAffected(tt.verify)
+
+		err := tt.write(d, f)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+		var want = tt.affected
+		if tt.err != nil {
+			want = 0
+		}
+		var r AffectedReporter = d
+		if r.RowsAffected() != want {
+			t.Errorf("%s: RowsAffected() = %d, want %d", tt.name, r.RowsAffected(), want)
+		}
+	}
+}
+
+func updateRecord(d *DbRecorder, _ *fakeDB) error { return d.Update() }
+
+func deleteRecord(d *DbRecorder, _ *fakeDB) error { return d.Delete() }
+
+// schemaRecord has a field of every type GetSchema knows.
+type schemaRecord struct {
+	Model
+	Name     string               `orm:"name" length:"32"`
+	Nick     *string              `orm:"nick,NULL"`
+	Note     sql.NullString       `orm:"note,NULL"`
+	Age      int                  `orm:"age"`
+	Score    float64              `orm:"score"`
+	Active   bool                 `orm:"active"`
+	Price    Decimal              `orm:"price" precision:"10" scale:"2"`
+	Discount NullDecimal          `orm:"discount,NULL"`
+	Seen     Time                 `orm:"seen"`
+	LeftAt   Time                 `orm:"left_at,NULL"`
+	Born     Date                 `orm:"born,NULL"`
+	Opens    TimeOfDay            `orm:"opens,NULL"`
+	Tags     Strings              `orm:"tags"`
+	Ids      Int64s               `orm:"ids"`
+	Meta     JSON[map[string]int] `orm:"meta"`
+}
+
+func TestGetSchemaFlavors(t *testing.T) {
+	tests := []struct {
+		flavor     string
+		want, fail []string
+	}{
+		{"mysql", []string{"`seen` datetime default now()", "`left_at` datetime null", "`tags` json"}, nil},
+		{"postgres", []string{`"seen" timestamp default now()`, `"left_at" timestamp null`, `"tags" jsonb`, `"meta" jsonb`}, []string{"datetime"}},
+		{"sqlite3", []string{`"seen" datetime default CURRENT_TIMESTAMP`, `"left_at" datetime null`}, []string{"now()"}},
+	}
+	for _, tt := range tests {
+		schema := New(nil, tt.flavor).Bind("records", &schemaRecord{}).GetSchema()
+		for _, w := range tt.want {
+			if !strings.Contains(schema, w) {
+				t.Errorf("%s: schema lacks %q:\n%s", tt.flavor, w, schema)
+			}
+		}
+		for _, f := range tt.fail {
+			if strings.Contains(schema, f) {
+				t.Errorf("%s: schema has %q:\n%s", tt.flavor, f, schema)
+			}
+		}
+	}
+}
@@ -5,8 +5,9 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
-	"github.com/sirupsen/logrus"
-	"regexp"
+	"fmt"
+	"reflect"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -17,65 +18,367 @@ const (
 	DatetimeLayout = DateLayout + " " + TimeLayout
 )
 
+var (
+	// TimeFormat is the layout of a Time in JSON, text and String.
+	TimeFormat = DatetimeLayout
+	// TimeLayouts are tried in order to parse a Time that is neither RFC 3339
+	// nor a Unix timestamp.
+	TimeLayouts = []string{
+		DatetimeLayout,
+		"2006-01-02 15:04:05.999999999",
+		"2006-01-02 15:04:05.999999999-07:00",
+		"2006-01-02T15:04:05",
+		DateLayout,
+	}
+	// TimeLocation is the location of times parsed without an offset, and of
+	// formatted times.
+	TimeLocation = time.Local
+)
+
+// Time is a nullable datetime column. It reads and writes the layouts above
+// in JSON, text (query parameters, form values) and from drivers returning
+// datetimes as strings.
 type Time struct {
 	sql.NullTime
 }
 
-func (t *Time) MarshalJSON() ([]byte, error) {
+// NewTime returns a valid Time.
+func NewTime(t time.Time) Time {
+	return Time{sql.NullTime{Time: t, Valid: true}}
+}
+
+// ParseTime parses an RFC 3339 time, a time in one of TimeLayouts, or a Unix
+// timestamp in seconds or milliseconds. Timestamps need at least 10 digits, so
+// that a compact date such as 20240501 is not read as seconds since 1970. An
+// empty string or null is the NULL Time.
+func ParseTime(v string) (Time, error) {
+	v = strings.TrimSpace(v)
+	if v == "" || v == "null" {
+		return Time{}, nil
+	}
+
+	if tt, err := time.Parse(time.RFC3339Nano, v); err == nil {
+		return NewTime(tt), nil
+	}
+	for _, layout := range TimeLayouts {
+		if tt, err := time.ParseInLocation(layout, v, TimeLocation); err == nil {
+			return NewTime(tt), nil
+		}
+	}
+	if len(strings.TrimPrefix(v, "-")) >= 10 {
+		if t, ok := parseUnix(v); ok {
+			return t, nil
+		}
+	}
+	return Time{}, fmt.Errorf("dorm: cannot parse time %q", v)
+}
+
+// parseUnix parses a Unix timestamp, in milliseconds when it has more than 11
+// digits.
+func parseUnix(v string) (Time, bool) {
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return Time{}, false
+	}
+	if len(strings.TrimPrefix(v, "-")) > 11 {
+		return NewTime(time.UnixMilli(n)), true
+	}
+	return NewTime(time.Unix(n, 0)), true
+}
+
+func (t Time) MarshalJSON() ([]byte, error) {
 	if t.Valid {
-		return json.Marshal(t.Time.Format(DatetimeLayout))
+		return json.Marshal(t.String())
 	}
 	return json.Marshal(nil)
 }
 
-func (t *Time) String() string {
+func (t Time) String() string {
 	if t.Valid {
-	 return t.Time.Format(DatetimeLayout)
+		return t.Time.In(TimeLocation).Format(TimeFormat)
 	}
 	return ""
 }
 
-// UnmarshalJSON implements the json.Unmarshaler interface.
-// The time is expected to be a quoted string in RFC 3339 format.
+// UnmarshalJSON implements the json.Unmarshaler interface. It accepts null,
+// a number, which is a Unix timestamp of any length, or a string as accepted
+// by ParseTime.
 func (t *Time) UnmarshalJSON(data []byte) (err error) {
 	if bytes.Equal(data, []byte("null")) {
+		*t = Time{}
 		return nil
-	} else {
-		var v = strings.TrimSpace(strings.ReplaceAll(string(data), "\"", ""))
-		var layout = ""
-		if ok, _ := regexp.Match(`^\d{4}-\d{1,2}-\d{1,2}$`, []byte(v)); ok {
-			layout = DateLayout
-		}
-		if ok, _ := regexp.Match(`^\d{4}-\d{1,2}-\d{1,2} \d{2}:\d{2}:\d{2}$`, []byte(v)); ok {
-			layout = DatetimeLayout
-		}
-		if layout == "" {
-			return nil
-		}
-		tt, err := time.ParseInLocation(layout, v, time.Local)
-		if err != nil {
-			logrus.Errorf("run here parse time error: %v", err)
-			return err
+	}
+	if len(data) > 0 && data[0] != '"' {
+		var ok bool
+		if *t, ok = parseUnix(string(data)); !ok {
+			return fmt.Errorf("dorm: cannot parse time %s", data)
 		}
-		*t = Time{sql.NullTime{Valid: true, Time: tt}}
 		return nil
 	}
+	var v string
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*t, err = ParseTime(v)
+	return err
+}
+
+// MarshalText implements encoding.TextMarshaler. The NULL Time is empty.
+func (t Time) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
 }
 
-func (t *Time) GetDate() string {
+// UnmarshalText implements encoding.TextUnmarshaler, see ParseTime.
+func (t *Time) UnmarshalText(data []byte) (err error) {
+	*t, err = ParseTime(string(data))
+	return err
+}
+
+// Value implements driver.Valuer.
+func (t Time) Value() (driver.Value, error) {
+	if !t.Valid {
+		return nil, nil
+	}
+	return t.Time, nil
+}
+
+// Scan implements sql.Scanner. Besides time.Time, it reads the strings of
+// drivers that do not parse datetimes (mysql without parseTime, sqlite) and
+// Unix timestamps.
+func (t *Time) Scan(input interface{}) (err error) {
+	switch v := input.(type) {
+	case nil:
+		*t = Time{}
+	case time.Time:
+		*t = NewTime(v)
+	case []byte:
+		*t, err = ParseTime(string(v))
+	case string:
+		*t, err = ParseTime(v)
+	case int64:
+		*t = NewTime(time.Unix(v, 0))
+	default:
+		return fmt.Errorf("dorm: cannot scan %T into a Time", input)
+	}
+	return err
+}
+
+func (t Time) GetDate() string {
 	if t.Valid {
-		return t.Time.Format(DateLayout)
+		return t.Time.In(TimeLocation).Format(DateLayout)
 	}
 	return ""
 }
 
-func (t *Time) GetDateTime() string {
+func (t Time) GetDateTime() string {
 	if t.Valid {
-		return t.Time.Format(DatetimeLayout)
+		return t.Time.In(TimeLocation).Format(DatetimeLayout)
 	}
 	return ""
 }
 
+// Date is a nullable date column, without time of day. Its JSON and text
+// form is 2006-01-02.
+type Date struct {
+	sql.NullTime
+}
+
+// NewDate returns a valid Date.
+func NewDate(year int, month time.Month, day int) Date {
+	return Date{sql.NullTime{Time: time.Date(year, month, day, 0, 0, 0, 0, time.UTC), Valid: true}}
+}
+
+// ParseDate parses a 2006-01-02 date. A longer input, such as a datetime, is
+// cut to its date. An empty string or null is the NULL Date.
+func ParseDate(v string) (Date, error) {
+	v = strings.TrimSpace(v)
+	if v == "" || v == "null" {
+		return Date{}, nil
+	}
+	if len(v) > len(DateLayout) {
+		v = v[:len(DateLayout)]
+	}
+	tt, err := time.Parse(DateLayout, v)
+	if err != nil {
+		return Date{}, fmt.Errorf("dorm: cannot parse date %q", v)
+	}
+	return Date{sql.NullTime{Time: tt, Valid: true}}, nil
+}
+
+func (d Date) String() string {
+	if d.Valid {
+		return d.Time.Format(DateLayout)
+	}
+	return ""
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.Valid {
+		return json.Marshal(d.String())
+	}
+	return json.Marshal(nil)
+}
+
+func (d *Date) UnmarshalJSON(data []byte) (err error) {
+	var v *string
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v == nil {
+		*d = Date{}
+		return nil
+	}
+	*d, err = ParseDate(*v)
+	return err
+}
+
+func (d Date) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Date) UnmarshalText(data []byte) (err error) {
+	*d, err = ParseDate(string(data))
+	return err
+}
+
+// Value implements driver.Valuer. The date is sent as a 2006-01-02 string,
+// which every driver stores as is, whatever its time zone settings.
+func (d Date) Value() (driver.Value, error) {
+	if !d.Valid {
+		return nil, nil
+	}
+	return d.String(), nil
+}
+
+// Scan implements sql.Scanner, from a time.Time or a string.
+func (d *Date) Scan(input interface{}) (err error) {
+	switch v := input.(type) {
+	case nil:
+		*d = Date{}
+	case time.Time:
+		*d = NewDate(v.Date())
+	case []byte:
+		*d, err = ParseDate(string(v))
+	case string:
+		*d, err = ParseDate(v)
+	default:
+		return fmt.Errorf("dorm: cannot scan %T into a Date", input)
+	}
+	return err
+}
+
+// TimeOfDay is a nullable time column, a time of day without date. Its JSON and
+// text form is 15:04:05.
+type TimeOfDay struct {
+	Hour, Minute, Second int
+	Valid                bool
+}
+
+// NewTimeOfDay returns a valid TimeOfDay.
+func NewTimeOfDay(hour, minute, second int) TimeOfDay {
+	return TimeOfDay{Hour: hour, Minute: minute, Second: second, Valid: true}
+}
+
+// ParseTimeOfDay parses 15:04:05 or 15:04. Fractional seconds and time zones,
+// as returned by some drivers, are dropped. An empty string or null is the NULL
+// TimeOfDay.
+func ParseTimeOfDay(v string) (TimeOfDay, error) {
+	v = strings.TrimSpace(v)
+	if v == "" || v == "null" {
+		return TimeOfDay{}, nil
+	}
+
+	var t = TimeOfDay{Valid: true}
+	var in = v
+	if i := strings.IndexAny(in, ".+-Z"); i > 0 {
+		in = in[:i]
+	}
+	parts := strings.Split(in, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return TimeOfDay{}, fmt.Errorf("dorm: cannot parse time of day %q", v)
+	}
+	for i, dest := range []*int{&t.Hour, &t.Minute, &t.Second}[:len(parts)] {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil || n < 0 || (i == 0 && n > 23) || (i > 0 && n > 59) {
+			return TimeOfDay{}, fmt.Errorf("dorm: cannot parse time of day %q", v)
+		}
+		*dest = n
+	}
+	return t, nil
+}
+
+func (t TimeOfDay) String() string {
+	if t.Valid {
+		return fmt.Sprintf("%02d:%02d:%02d", t.Hour, t.Minute, t.Second)
+	}
+	return ""
+}
+
+// On returns the time of day on the date of d, in the location of d.
+func (t TimeOfDay) On(d time.Time) time.Time {
+	y, m, day := d.Date()
+	return time.Date(y, m, day, t.Hour, t.Minute, t.Second, 0, d.Location())
+}
+
+func (t TimeOfDay) MarshalJSON() ([]byte, error) {
+	if t.Valid {
+		return json.Marshal(t.String())
+	}
+	return json.Marshal(nil)
+}
+
+func (t *TimeOfDay) UnmarshalJSON(data []byte) (err error) {
+	var v *string
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v == nil {
+		*t = TimeOfDay{}
+		return nil
+	}
+	*t, err = ParseTimeOfDay(*v)
+	return err
+}
+
+func (t TimeOfDay) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *TimeOfDay) UnmarshalText(data []byte) (err error) {
+	*t, err = ParseTimeOfDay(string(data))
+	return err
+}
+
+// Value implements driver.Valuer, as a 15:04:05 string.
+func (t TimeOfDay) Value() (driver.Value, error) {
+	if !t.Valid {
+		return nil, nil
+	}
+	return t.String(), nil
+}
+
+// Scan implements sql.Scanner, from a string or a time.Time.
+func (t *TimeOfDay) Scan(input interface{}) (err error) {
+	switch v := input.(type) {
+	case nil:
+		*t = TimeOfDay{}
+	case time.Time:
+		*t = NewTimeOfDay(v.Clock())
+	case []byte:
+		*t, err = ParseTimeOfDay(string(v))
+	case string:
+		*t, err = ParseTimeOfDay(v)
+	default:
+		return fmt.Errorf("dorm: cannot scan %T into a TimeOfDay", input)
+	}
+	return err
+}
+
+var (
+	dateType      = reflect.TypeOf(Date{})
+	timeOfDayType = reflect.TypeOf(TimeOfDay{})
+)
+
 // Strings support []string
 type Strings []string
 
@@ -88,7 +391,11 @@ func (c *Strings) Scan(input interface{}) error {
 	if input == nil {
 		return json.Unmarshal([]byte("[]"), c)
 	}
-	return json.Unmarshal(input.([]byte), c)
+	b, err := jsonBytes(input)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, c)
 }
 
 // Int64s support []int64
@@ -103,5 +410,74 @@ func (c *Int64s) Scan(input interface{}) error {
 	if input == nil {
 		return json.Unmarshal([]byte("[]"), c)
 	}
-	return json.Unmarshal(input.([]byte), c)
+	b, err := jsonBytes(input)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, c)
+}
+
+// JSON stores any value as a json column (jsonb on postgres):
+//
+// 	type Order struct {
+// 		Id    int64                         `orm:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+// 		Items dorm.JSON[[]Item]             `orm:"items"`
+// 		Attrs dorm.JSON[map[string]float64] `orm:"attrs,NULL"`
+// 	}
+//
+// A NULL column scans to the zero value of T. It marshals to JSON as Data
+// itself, without the wrapper.
+type JSON[T any] struct {
+	Data T
+}
+
+// NewJSON wraps v.
+func NewJSON[T any](v T) JSON[T] {
+	return JSON[T]{Data: v}
+}
+
+func (j JSON[T]) Value() (driver.Value, error) {
+	b, err := json.Marshal(j.Data)
+	return string(b), err
+}
+
+func (j *JSON[T]) Scan(input interface{}) error {
+	var zero T
+	j.Data = zero
+	if input == nil {
+		return nil
+	}
+	b, err := jsonBytes(input)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, &j.Data)
+}
+
+func (j JSON[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(j.Data)
+}
+
+func (j *JSON[T]) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &j.Data)
+}
+
+func (JSON[T]) jsonColumn() {}
+
+// jsonColumn is implemented by JSON[T], whatever T, for parseType.
+type jsonColumn interface {
+	jsonColumn()
+}
+
+var jsonColumnType = reflect.TypeOf((*jsonColumn)(nil)).Elem()
+
+// jsonBytes returns the raw JSON of a json column as given by the driver.
+func jsonBytes(input interface{}) ([]byte, error) {
+	switch v := input.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	}
+	return nil, fmt.Errorf("dorm: cannot scan %T into a json column", input)
 }
\ No newline at end of file
@@ -0,0 +1,240 @@
+package dorm
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type item struct {
+	Sku string `json:"sku"`
+	Qty int    `json:"qty"`
+}
+
+// cart has json columns.
+type cart struct {
+	Id    int64                    `orm:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Items JSON[[]item]             `orm:"items"`
+	Attrs JSON[map[string]float64] `orm:"attrs,NULL"`
+}
+
+func TestJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input interface{}
+		want  []item
+		fail  bool
+	}{
+		{name: "bytes", input: []byte(`[{"sku":"a","qty":2}]`), want: []item{{"a", 2}}},
+		{name: "string", input: `[{"sku":"b","qty":1}]`, want: []item{{"b", 1}}},
+		{name: "NULL", input: nil, want: nil},
+		{name: "not json", input: "[", fail: true},
+		{name: "not text", input: int64(1), fail: true},
+	}
+	for _, tt := range tests {
+		j := NewJSON([]item{{"old", 1}})
+		err := j.Scan(tt.input)
+		if tt.fail {
+			if err == nil {
+				t.Errorf("%s: Scan() succeeded", tt.name)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(j.Data, tt.want) {
+			t.Errorf("%s: Scan() = %v, %+v, want %+v", tt.name, err, j.Data, tt.want)
+		}
+	}
+
+	v, err := NewJSON(map[string]int{"a": 1}).Value()
+	if err != nil || v != `{"a":1}` {
+		t.Errorf("Value() = %v, %v", v, err)
+	}
+
+	var c cart
+	if err := json.Unmarshal([]byte(`{"Id":1,"Items":[{"sku":"a","qty":2}],"Attrs":{"w":1.5}}`), &c); err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(c)
+	if err != nil || string(b) != `{"Id":1,"Items":[{"sku":"a","qty":2}],"Attrs":{"w":1.5}}` {
+		t.Errorf("json.Marshal() = %s, %v", b, err)
+	}
+
+	for flavor, cols := range map[string][2]string{
+		"mysql":    {"`items` json", "`attrs` json"},
+		"postgres": {`"items" jsonb`, `"attrs" jsonb`},
+	} {
+		_, d := openFake(t, flavor)
+		d.Bind("carts", &cart{})
+		schema := d.GetSchema()
+		if !strings.Contains(schema, cols[0]) || !strings.Contains(schema, cols[1]) {
+			t.Errorf("%s: GetSchema() = %s", flavor, schema)
+		}
+	}
+}
+
+func TestArraysScan(t *testing.T) {
+	for _, input := range []interface{}{[]byte(`["a","b"]`), `["a","b"]`, nil} {
+		var s Strings
+		if err := s.Scan(input); err != nil || (input != nil && !reflect.DeepEqual(s, Strings{"a", "b"})) || s == nil {
+			t.Errorf("Strings.Scan(%#v) = %v, %v", input, s, err)
+		}
+	}
+	for _, input := range []interface{}{[]byte(`[1,2]`), `[1,2]`, nil} {
+		var n Int64s
+		if err := n.Scan(input); err != nil || (input != nil && !reflect.DeepEqual(n, Int64s{1, 2})) || n == nil {
+			t.Errorf("Int64s.Scan(%#v) = %v, %v", input, n, err)
+		}
+	}
+}
+
+func TestDateScan(t *testing.T) {
+	loc := time.FixedZone("UTC-5", -5*3600)
+	tests := []struct {
+		name  string
+		input interface{}
+		want  string
+		fail  bool
+	}{
+		{name: "NULL", input: nil, want: ""},
+		{name: "time.Time", input: time.Date(2024, 5, 1, 23, 30, 0, 0, loc), want: "2024-05-01"},
+		{name: "bytes", input: []byte("2024-05-01"), want: "2024-05-01"},
+		{name: "string", input: "2024-05-01", want: "2024-05-01"},
+		{name: "datetime string", input: "2024-05-01 23:30:00", want: "2024-05-01"},
+		{name: "rfc3339 bytes", input: []byte("2024-05-01T00:00:00Z"), want: "2024-05-01"},
+		{name: "empty", input: "", want: ""},
+		{name: "bad date", input: "2024-02-30", fail: true},
+		{name: "integer", input: int64(20240501), fail: true},
+	}
+	for _, tt := range tests {
+		d := NewDate(2000, 1, 1)
+		err := d.Scan(tt.input)
+		if tt.fail {
+			if err == nil {
+				t.Errorf("%s: Scan() = %v, want an error", tt.name, d)
+			}
+			continue
+		}
+		if err != nil || d.String() != tt.want || d.Valid != (tt.want != "") {
+			t.Errorf("%s: Scan() = %q, %v, want %q", tt.name, d, err, tt.want)
+		}
+		v, _ := d.Value()
+		if (v == nil) != (tt.want == "") || (v != nil && v != tt.want) {
+			t.Errorf("%s: Value() = %#v", tt.name, v)
+		}
+	}
+
+	var d Date
+	if err := json.Unmarshal([]byte(`"2024-05-01"`), &d); err != nil || d != NewDate(2024, 5, 1) {
+		t.Errorf("json.Unmarshal() = %v, %v", d, err)
+	}
+	if err := json.Unmarshal([]byte(`null`), &d); err != nil || d.Valid {
+		t.Errorf("json.Unmarshal(null) = %v, %v", d, err)
+	}
+	if b, _ := json.Marshal(Date{}); string(b) != "null" {
+		t.Errorf("json.Marshal() of NULL = %s", b)
+	}
+}
+
+func TestTimeOfDayScan(t *testing.T) {
+	tests := []struct {
+		name  string
+		input interface{}
+		want  string
+		fail  bool
+	}{
+		{name: "NULL", input: nil, want: ""},
+		{name: "time.Time", input: time.Date(0, 1, 1, 9, 5, 7, 0, time.UTC), want: "09:05:07"},
+		{name: "bytes", input: []byte("09:05:07"), want: "09:05:07"},
+		{name: "string without seconds", input: "09:05", want: "09:05:00"},
+		{name: "fractional seconds", input: "23:59:59.123456", want: "23:59:59"},
+		{name: "time zone", input: "12:00:00+02", want: "12:00:00"},
+		{name: "negative time zone", input: "12:00:00-05:00", want: "12:00:00"},
+		{name: "hour out of range", input: "24:00:00", fail: true},
+		{name: "minute out of range", input: "12:60", fail: true},
+		{name: "no minutes", input: "12", fail: true},
+		{name: "float", input: 1.5, fail: true},
+	}
+	for _, tt := range tests {
+		var tod TimeOfDay
+		err := tod.Scan(tt.input)
+		if tt.fail {
+			if err == nil {
+				t.Errorf("%s: Scan() = %v, want an error", tt.name, tod)
+			}
+			continue
+		}
+		if err != nil || tod.String() != tt.want || tod.Valid != (tt.want != "") {
+			t.Errorf("%s: Scan() = %q, %v, want %q", tt.name, tod, err, tt.want)
+		}
+	}
+
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	if got := NewTimeOfDay(9, 30, 0).On(day); !got.Equal(day.Add(9*time.Hour + 30*time.Minute)) {
+		t.Errorf("On() = %v", got)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	defer func(l *time.Location) { TimeLocation = l }(TimeLocation)
+	TimeLocation = loc
+
+	tests := []struct {
+		in   string
+		want time.Time
+		null bool
+		fail bool
+	}{
+		{in: "", null: true},
+		{in: " null ", null: true},
+		{in: "2024-05-01T12:00:00Z", want: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+		{in: "2024-05-01T12:00:00.5+02:00", want: time.Date(2024, 5, 1, 10, 0, 0, 5e8, time.UTC)},
+		{in: "1714564800", want: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+		{in: "1714564800123", want: time.Date(2024, 5, 1, 12, 0, 0, 123e6, time.UTC)},
+		{in: "-1000000000", want: time.Date(1938, 4, 24, 22, 13, 20, 0, time.UTC)},
+		{in: "20240501", fail: true},
+		{in: "86400", fail: true},
+		{in: "2024-05-01 12:00:00", want: time.Date(2024, 5, 1, 12, 0, 0, 0, loc)},
+		{in: "2024-05-01 12:00:00.25", want: time.Date(2024, 5, 1, 12, 0, 0, 25e7, loc)},
+		{in: "2024-05-01 12:00:00.25+00:00", want: time.Date(2024, 5, 1, 12, 0, 0, 25e7, time.UTC)},
+		{in: "2024-05-01T12:00:00", want: time.Date(2024, 5, 1, 12, 0, 0, 0, loc)},
+		{in: "2024-05-01", want: time.Date(2024, 5, 1, 0, 0, 0, 0, loc)},
+		{in: "2024-13-01", fail: true},
+		{in: "yesterday", fail: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTime(tt.in)
+		switch {
+		case tt.fail:
+			if err == nil {
+				t.Errorf("ParseTime(%q) = %v, want an error", tt.in, got)
+			}
+		case err != nil:
+			t.Errorf("ParseTime(%q): %v", tt.in, err)
+		case tt.null:
+			if got.Valid {
+				t.Errorf("ParseTime(%q) = %v, want NULL", tt.in, got)
+			}
+		case !got.Valid || !got.Time.Equal(tt.want):
+			t.Errorf("ParseTime(%q) = %v, want %v", tt.in, got.Time, tt.want)
+		}
+	}
+
+	// a JSON number is a timestamp whatever its length
+	for in, want := range map[string]time.Time{
+		`0`:             time.Unix(0, 0),
+		`-86400`:        time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC),
+		`1714564800123`: time.Date(2024, 5, 1, 12, 0, 0, 123e6, time.UTC),
+	} {
+		var got Time
+		if err := json.Unmarshal([]byte(in), &got); err != nil || !got.Valid || !got.Time.Equal(want) {
+			t.Errorf("json.Unmarshal(%s) = %v, %v, want %v", in, got.Time, err, want)
+		}
+	}
+	var got Time
+	if err := json.Unmarshal([]byte(`"20240501"`), &got); err == nil {
+		t.Errorf("json.Unmarshal(\"20240501\") = %v, want an error", got.Time)
+	}
+}
@@ -1,6 +1,7 @@
 package dorm
 
 import (
+	"fmt"
 	"github.com/Masterminds/squirrel"
 	"reflect"
 )
@@ -25,14 +26,19 @@ type WhereFunc func(query squirrel.SelectBuilder) squirrel.SelectBuilder
 //
 // The WhereFunc will be given a SELECT d.Columns() FROM d.TableName() statement,
 // and may modify it. Note that while joining is supported, changing the column
-// list will have unpredictable side effects. It is advised that joins be done
-// using Squirrel instead.
+// list will have unpredictable side effects. Use ListJoin to select the
+// columns of joined tables as well.
 //
-// This will return a list of Recorder objects, where the underlying type
-// of each matches the underlying type of the passed-in 'd' Recorder.
+// This will return a list of Recorder objects, each bound to a new Record of
+// the type bound to d, and of the concrete type of d. If that type defines
+// Clone, as DbRecorder and the Recorder of package dormtest do, each Recorder
+// is a clone of d; otherwise it is a copy of d, with a clone of the DbRecorder
+// it embeds.
+//
+// A Recorder implementing Querier runs the query itself.
 func ListWhere(d Recorder, pagination *Pagination, fn WhereFunc) ([]Recorder, error) {
-	var tn = d.TableName()
-	var cols = d.Columns(true)
+	var tn = Quote(d.Driver(), d.TableName())
+	var cols = quoteAll(d.Driver(), d.Columns(true))
 	var buf []Recorder
 
 	// Base query
@@ -45,6 +51,9 @@ func ListWhere(d Recorder, pagination *Pagination, fn WhereFunc) ([]Recorder, er
 	if pagination != nil && pagination.required() {
 		q = q.Limit(pagination.limit()).Offset(pagination.offset())
 	}
+	if qr, ok := d.(Querier); ok {
+		return qr.QueryList(q)
+	}
 	rows, err := q.Query()
 	if err != nil || rows == nil {
 		return buf, err
@@ -52,16 +61,11 @@ func ListWhere(d Recorder, pagination *Pagination, fn WhereFunc) ([]Recorder, er
 
 	defer rows.Close()
 
-	v := reflect.Indirect(reflect.ValueOf(d))
-	t := v.Type()
 	for rows.Next() {
-		nv := reflect.New(t)
-
 		// Bind an empty base object. Basically, we fetch the object out of
-		// the DbRecorder, and then construct an empty one.
-		rec := reflect.New(reflect.Indirect(reflect.ValueOf(d.(*DbRecorder).record)).Type())
-		s := nv.Interface().(Recorder)
-		s.Bind(d.TableName(), rec.Interface())
+		// the Recorder, and then construct an empty one.
+		rec := reflect.New(reflect.Indirect(reflect.ValueOf(d.Interface())).Type())
+		s := bindNew(d, rec.Interface())
 
 		dest := s.FieldReferences(true)
 		err := rows.Scan(dest...)
@@ -74,51 +78,168 @@ func ListWhere(d Recorder, pagination *Pagination, fn WhereFunc) ([]Recorder, er
 	return buf, rows.Err()
 }
 
+// ListIds returns the primary key of every row matched by fn.
+//
+// Deprecated: ListIds only handles a single integer key. Use Pluck or PluckKeys.
 func ListIds(d Recorder, fn WhereFunc) ([]int64, error) {
-	var tn = d.TableName()
-	var ids = make([]int64, 0)
-	var q = squirrel.Select("id").From(tn).RunWith(d.DB())
+	var key = "id"
+	if k := keyOf(d); len(k) == 1 {
+		key = k[0]
+	}
+	return Pluck[int64](d, key, fn)
+}
+
+// Pluck selects a single column and returns its value for every row.
+//
+// A column name is quoted for the flavor, see Quote; an expression such as
+// COUNT(*) or LOWER(email) is used verbatim. Each value is scanned into a T,
+// so any type accepted by sql.Rows.Scan works:
+//
+// 	emails, err := dorm.Pluck[string](d, "email", func(q squirrel.SelectBuilder) squirrel.SelectBuilder {
+// 		return q.Where(squirrel.Eq{"status": "active"})
+// 	})
+func Pluck[T any](d Recorder, column string, fn WhereFunc) ([]T, error) {
+	q := d.Builder().Select(Quote(d.Driver(), column)).From(Quote(d.Driver(), d.TableName()))
 	q = fn(q)
+
 	rows, err := q.Query()
 	if err != nil {
-		return ids, err
+		return nil, err
 	}
+	defer rows.Close()
 
+	var buf = make([]T, 0)
+	for rows.Next() {
+		var v T
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		buf = append(buf, v)
+	}
+	return buf, rows.Err()
+}
+
+// PluckMap selects two columns and returns them as a map from the first to the second.
+//
+// When several rows share a key, the last one wins.
+func PluckMap[K comparable, V any](d Recorder, keyColumn, valueColumn string, fn WhereFunc) (map[K]V, error) {
+	q := d.Builder().Select(Quote(d.Driver(), keyColumn), Quote(d.Driver(), valueColumn)).From(Quote(d.Driver(), d.TableName()))
+	q = fn(q)
+
+	rows, err := q.Query()
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
+	var buf = make(map[K]V)
 	for rows.Next() {
-		var id int64
-		err := rows.Scan(&id)
-		if err != nil {
-			return ids, err
+		var k K
+		var v V
+		if err := rows.Scan(&k, &v); err != nil {
+			return nil, err
+		}
+		buf[k] = v
+	}
+	return buf, rows.Err()
+}
+
+// Tuple holds the values of a primary key, in the order given by Key(), see
+// Keyer.
+type Tuple []interface{}
+
+// PluckKeys returns the primary key of every row matched by fn.
+//
+// This works for composite keys as well. The values are scanned into fields of
+// the same types as the key fields of the bound Record.
+func PluckKeys(d Recorder, fn WhereFunc) ([]Tuple, error) {
+	var key = keyOf(d)
+	if len(key) == 0 {
+		return nil, fmt.Errorf("dorm: %s has no PRIMARY_KEY", d.TableName())
+	}
+
+	// Bind an empty record of the same kind to get typed scan destinations.
+	rec := reflect.New(reflect.Indirect(reflect.ValueOf(d.Interface())).Type())
+	s := bindNew(d, rec.Interface())
+
+	var refs = make([]interface{}, 0, len(key))
+	var cols, all = s.Columns(true), s.FieldReferences(true)
+	for _, k := range key {
+		for i, col := range cols {
+			if col == k {
+				refs = append(refs, all[i])
+			}
 		}
-		ids = append(ids, id)
 	}
-	return ids, nil
+
+	q := d.Builder().Select(quoteAll(d.Driver(), key)...).From(Quote(d.Driver(), d.TableName()))
+	q = fn(q)
+
+	rows, err := q.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var buf = make([]Tuple, 0)
+	for rows.Next() {
+		if err := rows.Scan(refs...); err != nil {
+			return nil, err
+		}
+		var t = make(Tuple, len(refs))
+		for i, ref := range refs {
+			t[i] = reflect.ValueOf(ref).Elem().Interface()
+		}
+		buf = append(buf, t)
+	}
+	return buf, rows.Err()
+}
+
+// Cloner is implemented by Recorders that can make an unbound copy of
+// themselves, which ListWhere, PluckKeys and ListJoin bind the records they
+// return to. A Clone promoted from an embedded DbRecorder, which returns a
+// plain DbRecorder, is ignored: the wrapper is copied instead.
+type Cloner interface {
+	Clone() Recorder
 }
 
 type WhereCountFunc func(query squirrel.SelectBuilder) squirrel.SelectBuilder
 
+// Querier is implemented by Recorders that run the queries of ListWhere and
+// Count themselves instead of sending them to DB(), such as the in-memory
+// Recorder of package dormtest. The queries are built as for a DbRecorder.
+type Querier interface {
+	// QueryList returns the Records selected by q, a SELECT of Columns(true).
+	QueryList(q squirrel.SelectBuilder) ([]Recorder, error)
+	// QueryCount returns the number of rows matched by q, a SELECT COUNT(*).
+	QueryCount(q squirrel.SelectBuilder) (int64, error)
+}
+
+// Count returns the number of rows matched by fn. A Recorder implementing
+// Querier runs the query itself.
 func Count(d Recorder, fn WhereCountFunc) (int64, error) {
-	var tn = d.TableName()
+	var tn = Quote(d.Driver(), d.TableName())
 
 	q := d.Builder().Select("COUNT(*)").From(tn)
 
 	q = fn(q)
+	if qr, ok := d.(Querier); ok {
+		return qr.QueryCount(q)
+	}
 
 	total := int64(0)
-	err := q.QueryRow().Scan(&total)
+	err := queryRow(q).Scan(&total)
 
 	return total, err
 }
 
 func QueryOne(d Recorder, column string, fn WhereCountFunc) (string, error) {
-	var tn = d.TableName()
-	q := d.Builder().Select(column).From(tn)
+	var tn = Quote(d.Driver(), d.TableName())
+	q := d.Builder().Select(Quote(d.Driver(), column)).From(tn)
 	q = fn(q)
 
 	co := ""
-	err := q.Limit(1).QueryRow().Scan(&co)
+	err := queryRow(q.Limit(1)).Scan(&co)
 	return co, err
 }
 
@@ -0,0 +1,174 @@
+package dorm
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// member has a composite key.
+type member struct {
+	TeamId int64  `orm:"team_id,PRIMARY_KEY"`
+	UserId string `orm:"user_id,PRIMARY_KEY"`
+	Role   string `orm:"role"`
+}
+
+func activeOnly(q squirrel.SelectBuilder) squirrel.SelectBuilder {
+	return q.Where(squirrel.Eq{"name": "bo"})
+}
+
+func TestPluck(t *testing.T) {
+	tests := []struct {
+		name   string
+		record Record
+		table  string
+		rows   *fakeRows
+		pluck  func(d Recorder) (interface{}, error)
+		sql    string
+		want   interface{}
+	}{
+		{
+			name:   "strings",
+			record: &account{}, table: "accounts",
+			rows: &fakeRows{[]string{"name"}, [][]driver.Value{{"bo"}, {"al"}}},
+			pluck: func(d Recorder) (interface{}, error) {
+				return Pluck[string](d, "name", activeOnly)
+			},
+			sql:  "SELECT `name` FROM `accounts` WHERE name = ?",
+			want: []string{"bo", "al"},
+		},
+		{
+			name:   "no rows",
+			record: &account{}, table: "accounts",
+			rows: &fakeRows{[]string{"id"}, nil},
+			pluck: func(d Recorder) (interface{}, error) {
+				return Pluck[int64](d, "id", activeOnly)
+			},
+			sql:  "SELECT `id` FROM `accounts` WHERE name = ?",
+			want: []int64{},
+		},
+		{
+			name:   "ids",
+			record: &account{}, table: "accounts",
+			rows: &fakeRows{[]string{"id"}, [][]driver.Value{{int64(1)}, {int64(2)}}},
+			pluck: func(d Recorder) (interface{}, error) {
+				return ListIds(d, activeOnly)
+			},
+			sql:  "SELECT `id` FROM `accounts` WHERE name = ?",
+			want: []int64{1, 2},
+		},
+		{
+			name:   "map",
+			record: &account{}, table: "accounts",
+			rows: &fakeRows{[]string{"id", "name"}, [][]driver.Value{{int64(1), "bo"}, {int64(2), "al"}, {int64(1), "cy"}}},
+			pluck: func(d Recorder) (interface{}, error) {
+				return PluckMap[int64, string](d, "id", "name", activeOnly)
+			},
+			sql:  "SELECT `id`, `name` FROM `accounts` WHERE name = ?",
+			want: map[int64]string{1: "cy", 2: "al"},
+		},
+		{
+			name:   "composite keys",
+			record: &member{}, table: "members",
+			rows: &fakeRows{[]string{"team_id", "user_id"}, [][]driver.Value{{int64(1), "bo"}, {int64(2), "al"}}},
+			pluck: func(d Recorder) (interface{}, error) {
+				return PluckKeys(d, activeOnly)
+			},
+			sql:  "SELECT `team_id`, `user_id` FROM `members` WHERE name = ?",
+			want: []Tuple{{int64(1), "bo"}, {int64(2), "al"}},
+		},
+	}
+	for _, tt := range tests {
+		f, d := openFake(t, "mysql")
+		d.Bind(tt.table, tt.record)
+		f.answer(tt.rows.columns, tt.rows.values...)
+
+		got, err := tt.pluck(d)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: = %#v, want %#v", tt.name, got, tt.want)
+		}
+		if sql, _ := f.last(); sql != tt.sql {
+			t.Errorf("%s: ran %q, want %q", tt.name, sql, tt.sql)
+		}
+	}
+
+	_, d := openFake(t, "mysql")
+	if _, err := PluckKeys(d.Bind("logs", &struct {
+		Line string `orm:"line"`
+	}{}), activeOnly); err == nil {
+		t.Error("PluckKeys without a PRIMARY_KEY succeeded")
+	}
+}
+
+// tracedRecorder wraps a DbRecorder, as application code might to log queries.
+type tracedRecorder struct {
+	*DbRecorder
+}
+
+func (r *tracedRecorder) Clone() Recorder {
+	return &tracedRecorder{r.DbRecorder.Clone().(*DbRecorder)}
+}
+
+func (r *tracedRecorder) Bind(table string, rec Record) Recorder {
+	r.DbRecorder.Bind(table, rec)
+	return r
+}
+
+// embeddedRecorder embeds a DbRecorder by value, and so only has the promoted
+// Clone, which returns a *DbRecorder.
+type embeddedRecorder struct {
+	DbRecorder
+}
+
+// sharedRecorder embeds a *DbRecorder without a Clone of its own.
+type sharedRecorder struct {
+	*DbRecorder
+}
+
+func TestListWhereKeepsRecorderType(t *testing.T) {
+	f, d := openFake(t, "mysql")
+	tests := []struct {
+		name string
+		d    Recorder
+		want reflect.Type
+	}{
+		{"DbRecorder", d.VerifyAffected(true), reflect.TypeOf(&DbRecorder{})},
+		{"wrapper with Clone", &tracedRecorder{d.Clone().(*DbRecorder)}, reflect.TypeOf(&tracedRecorder{})},
+		{"value embedding", &embeddedRecorder{*d.Clone().(*DbRecorder)}, reflect.TypeOf(&embeddedRecorder{})},
+		{"pointer embedding", &sharedRecorder{d.Clone().(*DbRecorder)}, reflect.TypeOf(&sharedRecorder{})},
+	}
+	for _, tt := range tests {
+		var bound = &member{}
+		tt.d.Bind("members", bound)
+		f.answer([]string{"team_id", "user_id", "role"}, []driver.Value{int64(1), "bo", "admin"})
+
+		list, err := List(tt.d, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(list) != 1 || reflect.TypeOf(list[0]) != tt.want {
+			t.Fatalf("%s: List() = %#v, want one %s", tt.name, list, tt.want)
+		}
+		if m := list[0].Interface().(*member); m.UserId != "bo" || m.Role != "admin" {
+			t.Errorf("%s: loaded %+v", tt.name, m)
+		}
+		if list[0].DB() == nil {
+			t.Errorf("%s: the listed Recorder has no connection", tt.name)
+		}
+		if tt.d.Interface() != bound {
+			t.Errorf("%s: List() rebound the original Recorder", tt.name)
+		}
+	}
+
+	f.answer([]string{"team_id", "user_id", "role"}, []driver.Value{int64(1), "bo", "admin"})
+	list, _ := List(tests[0].d, nil)
+	if !list[0].(*DbRecorder).verifyAffected {
+		t.Error("the VerifyAffected setting was not cloned")
+	}
+}
@@ -0,0 +1,62 @@
+package dorm
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+)
+
+var identRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*$`)
+
+// Quote quotes an identifier for the flavor: with backticks on mysql, double
+// quotes on postgres and sqlite. This makes reserved words (order, group, key)
+// and mixed case names usable as table and column names.
+//
+// A dotted name such as analytics.events or users.email is quoted part by
+// part. Parts already quoted, and * are kept; anything else that is not a
+// plain identifier, such as COUNT(*) or email AS e, is returned as is, so
+// that Quote can be applied to column-or-expression arguments.
+func Quote(flavor, name string) string {
+	var q = "`"
+	switch flavor {
+	case "postgres", "sqlite3", "sqlite":
+		q = `"`
+	}
+
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		switch {
+		case p == "*" && i == len(parts)-1:
+		case len(p) > 1 && strings.HasPrefix(p, q) && strings.HasSuffix(p, q):
+		case identRe.MatchString(p):
+			parts[i] = q + p + q
+		default:
+			return name
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// quoteAll quotes each of names, see Quote.
+func quoteAll(flavor string, names []string) []string {
+	var quoted = make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = Quote(flavor, n)
+	}
+	return quoted
+}
+
+// quoteKeys returns m with its column names quoted, for Where and SetMap.
+func quoteKeys(flavor string, m map[string]interface{}) map[string]interface{} {
+	var quoted = make(map[string]interface{}, len(m))
+	for k, v := range m {
+		quoted[Quote(flavor, k)] = v
+	}
+	return quoted
+}
+
+// quoteEq is quoteKeys for a squirrel.Eq.
+func quoteEq(flavor string, m map[string]interface{}) squirrel.Eq {
+	return squirrel.Eq(quoteKeys(flavor, m))
+}
@@ -0,0 +1,54 @@
+package dorm
+
+import "testing"
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		flavor, name, want string
+	}{
+		{"mysql", "order", "`order`"},
+		{"", "group", "`group`"},
+		{"postgres", "userId", `"userId"`},
+		{"sqlite3", "key", `"key"`},
+		{"sqlite", "key", `"key"`},
+		{"mysql", "analytics.events", "`analytics`.`events`"},
+		{"postgres", "analytics.events", `"analytics"."events"`},
+		{"postgres", "db.analytics.events", `"db"."analytics"."events"`},
+		{"mysql", "users.*", "`users`.*"},
+		{"mysql", "*", "*"},
+		{"mysql", "`order`", "`order`"},
+		{"postgres", `"Users".email`, `"Users"."email"`},
+		{"mysql", "`order`.id", "`order`.`id`"},
+		{"postgres", "`order`", "`order`"},
+		{"mysql", "COUNT(*)", "COUNT(*)"},
+		{"mysql", "email AS e", "email AS e"},
+		{"mysql", "users.email AS e", "users.email AS e"},
+		{"mysql", "a$b", "`a$b`"},
+		{"mysql", "1st", "1st"},
+		{"mysql", "users.", "users."},
+		{"mysql", "*.id", "*.id"},
+	}
+	for _, tt := range tests {
+		if got := Quote(tt.flavor, tt.name); got != tt.want {
+			t.Errorf("Quote(%q, %q) = %s, want %s", tt.flavor, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestQuotedStatements(t *testing.T) {
+	f, d := openFake(t, "postgres")
+	d.Bind("analytics.events", &account{Id: 1, Name: "bo"})
+
+	if err := d.Update(); err != nil {
+		t.Fatal(err)
+	}
+	if sql, _ := f.last(); sql != `UPDATE "analytics"."events" SET "name" = $1 WHERE "id" = $2` {
+		t.Errorf("Update ran %s", sql)
+	}
+	if err := d.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if sql, _ := f.last(); sql != `DELETE FROM "analytics"."events" WHERE "id" = $1` {
+		t.Errorf("Delete ran %s", sql)
+	}
+}
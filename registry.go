@@ -0,0 +1,230 @@
+package dorm
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// Hook is called around a write of a Record, with the Recorder bound to it.
+type Hook func(d Recorder) error
+
+// Hooks run around the Insert, Update and Delete of the Recorders of a DB, and
+// their ByTx variants. Before hooks run ahead of validation, so they can fill
+// in fields; an error from one aborts the write. An error from an After hook
+// is returned although the write is done.
+//
+// DeleteByKey runs the delete hooks too; bulk writes (UpdateWhere,
+// DeleteWhere) do not run hooks.
+type Hooks struct {
+	BeforeInsert, AfterInsert []Hook
+	BeforeUpdate, AfterUpdate []Hook
+	BeforeDelete, AfterDelete []Hook
+}
+
+type hookOp int
+
+const (
+	hookInsert hookOp = iota
+	hookUpdate
+	hookDelete
+)
+
+// around runs write between the hooks of op. h may be nil.
+func (h *Hooks) around(d Recorder, op hookOp, write func() error) error {
+	if h == nil {
+		return write()
+	}
+
+	var before, after []Hook
+	switch op {
+	case hookInsert:
+		before, after = h.BeforeInsert, h.AfterInsert
+	case hookUpdate:
+		before, after = h.BeforeUpdate, h.AfterUpdate
+	case hookDelete:
+		before, after = h.BeforeDelete, h.AfterDelete
+	}
+
+	for _, hook := range before {
+		if err := hook(d); err != nil {
+			return err
+		}
+	}
+	if err := write(); err != nil {
+		return err
+	}
+	for _, hook := range after {
+		if err := hook(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DB holds a connection with its flavor, naming and hooks, and the Records
+// registered on it, so that a Record is bound by its type only:
+//
+// 	db := dorm.NewDB(squirrel.NewStmtCacheProxy(conn), "mysql")
+// 	db.Register(&User{}, &Order{})
+//
+// 	u := &User{Id: 1}
+// 	err := db.Model(u).Load()
+//
+// Registered Records are listed by Models, for migrations and schema export.
+// Set Naming and Hooks before using the DB.
+type DB struct {
+	// Naming derives the tables and columns of the Records of the DB; nil
+	// means the package Naming.
+	Naming NamingStrategy
+	// Hooks run around the writes of the Records of the DB.
+	Hooks Hooks
+
+	db     squirrel.DBProxyBeginner
+	flavor string
+
+	mu     sync.RWMutex
+	tables map[reflect.Type]string
+	types  []reflect.Type
+}
+
+// NewDB creates a DB for a connection of the given flavor, see New.
+func NewDB(db squirrel.DBProxyBeginner, flavor string) *DB {
+	return &DB{db: db, flavor: flavor, tables: make(map[reflect.Type]string)}
+}
+
+// Conn returns the connection of the DB.
+func (db *DB) Conn() squirrel.DBProxyBeginner {
+	return db.db
+}
+
+// Driver returns the flavor of the DB.
+func (db *DB) Driver() string {
+	return db.flavor
+}
+
+// New returns an unbound DbRecorder using the connection, naming and hooks of
+// the DB.
+func (db *DB) New() *DbRecorder {
+	s := New(db.db, db.flavor)
+	s.naming = db.Naming
+	s.hooks = &db.Hooks
+	return s
+}
+
+// Register registers the types of records, which must be pointers to structs.
+// The table of each is given by its TableName method, or by the naming of the
+// DB. Registering a type again replaces its table. Register panics on a
+// record that is not a struct.
+func (db *DB) Register(records ...Record) {
+	for _, ar := range records {
+		db.RegisterTable("", ar)
+	}
+}
+
+// RegisterTable registers the type of ar with the given table. An empty table
+// is derived as for Register.
+func (db *DB) RegisterTable(table string, ar Record) {
+	t := reflect.Indirect(reflect.ValueOf(ar)).Type()
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("dorm: cannot register %T, not a struct", ar))
+	}
+	if table == "" {
+		table = tableOf(ar, db.New().namingStrategy())
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, ok := db.tables[t]; !ok {
+		db.types = append(db.types, t)
+	}
+	db.tables[t] = table
+}
+
+// Model returns a DbRecorder bound to ar, with the table its type was
+// registered with. Unregistered types are bound as by Bind("", ar).
+func (db *DB) Model(ar Record) *DbRecorder {
+	db.mu.RLock()
+	table := db.tables[reflect.Indirect(reflect.ValueOf(ar)).Type()]
+	db.mu.RUnlock()
+
+	s := db.New()
+	s.Bind(table, ar)
+	return s
+}
+
+// Models returns a DbRecorder bound to a new zero Record of each registered
+// type, in the order of registration:
+//
+// 	for _, m := range db.Models() {
+// 		fmt.Println(m.GetSchema())
+// 	}
+func (db *DB) Models() []*DbRecorder {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	var models = make([]*DbRecorder, len(db.types))
+	for i, t := range db.types {
+		models[i] = db.New()
+		models[i].Bind(db.tables[t], reflect.New(t).Interface())
+	}
+	return models
+}
+
+// Clone returns an unbound DbRecorder on the same connection, with the same
+// naming, hooks and VerifyAffected setting. It implements Cloner.
+func (s *DbRecorder) Clone() Recorder {
+	c := New(s.db, s.flavor)
+	c.builder = s.builder
+	c.naming, c.hooks = s.naming, s.hooks
+	c.verifyAffected = s.verifyAffected
+	return c
+}
+
+// bindNew binds rec to a Recorder set up as d, and of the same type: a clone
+// of d when d defines Clone itself, and otherwise a copy of d whose embedded
+// DbRecorder, if any, is replaced by a clone. Any other Recorder gets a
+// DbRecorder on the same connection.
+func bindNew(d Recorder, rec Record) Recorder {
+	var r Recorder
+	if c, ok := d.(Cloner); ok {
+		// a Clone promoted from an embedded DbRecorder returns a *DbRecorder
+		if r = c.Clone(); reflect.TypeOf(r) != reflect.TypeOf(d) {
+			r = nil
+		}
+	}
+	if r == nil {
+		r = copyRecorder(d)
+	}
+	// Bind may be promoted too, and return the embedded DbRecorder
+	r.Bind(d.TableName(), rec)
+	return r
+}
+
+// copyRecorder returns a new value of the type of d, as ListWhere used to
+// make, copied from d so as to keep its connection and settings.
+func copyRecorder(d Recorder) Recorder {
+	v := reflect.ValueOf(d)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return New(d.DB(), d.Driver())
+	}
+
+	nv := reflect.New(v.Elem().Type())
+	nv.Elem().Set(v.Elem())
+	// binding the copy must not rebind the DbRecorder d embeds
+	for i := 0; i < nv.Elem().NumField(); i++ {
+		sf, fv := nv.Elem().Type().Field(i), nv.Elem().Field(i)
+		switch {
+		case !sf.Anonymous:
+		case sf.Type == dbRecorderType:
+			fv.Set(reflect.ValueOf(fv.Addr().Interface().(*DbRecorder).Clone()).Elem())
+		case sf.Type == reflect.PtrTo(dbRecorderType) && !fv.IsNil():
+			fv.Set(reflect.ValueOf(fv.Interface().(*DbRecorder).Clone()))
+		}
+	}
+	return nv.Interface().(Recorder)
+}
+
+var dbRecorderType = reflect.TypeOf(DbRecorder{})
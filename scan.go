@@ -0,0 +1,181 @@
+package dorm
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+)
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// columnIndex maps the column names of a struct type to the index path of the
+// field that holds them, with the same rules as bound Records: columns are
+// named after the first part of the 'orm' tag, or the field name through Naming,
+// and embedded structs are descended into (see DbRecorder.scanFields).
+func columnIndex(t reflect.Type) map[string][]int {
+	var index = make(map[string][]int)
+	var depth = make(map[string]int)
+	walkFields(t, Naming, func(sf structField) {
+		if d, ok := depth[sf.column]; !ok || sf.depth < d {
+			index[sf.column], depth[sf.column] = sf.index, sf.depth
+		}
+	})
+	return index
+}
+
+// ScanOption changes how ScanAll and ScanOne match columns to fields.
+type ScanOption func(*scanOptions)
+
+type scanOptions struct {
+	strict bool
+}
+
+// Strict makes ScanAll and ScanOne fail when a result column has no matching
+// field, instead of silently discarding it.
+func Strict() ScanOption {
+	return func(o *scanOptions) {
+		o.strict = true
+	}
+}
+
+// ScanAll scans every row of a raw query into dest, which must be a pointer to
+// a slice of structs (or of pointers to structs), and closes rows.
+//
+// Result columns are matched to fields by name, not by position, using the
+// same naming as bound Records: the 'orm' tag, or the field name through Naming.
+// Fields of embedded structs such as Model are matched too.
+//
+// 	rows, err := db.Query(`WITH ranked AS (...) SELECT id, email, rank FROM ranked`)
+// 	if err != nil {
+// 		return err
+// 	}
+// 	var users []UserRank
+// 	err = dorm.ScanAll(rows, &users)
+//
+// Columns without a matching field are ignored, unless Strict is given.
+func ScanAll(rows *sql.Rows, dest interface{}, opts ...ScanOption) error {
+	defer rows.Close()
+
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("dorm: ScanAll needs a pointer to a slice, got %T", dest)
+	}
+	slice := v.Elem()
+	elem := slice.Type().Elem()
+	isPtr := elem.Kind() == reflect.Ptr
+	if isPtr {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return fmt.Errorf("dorm: ScanAll needs a slice of structs, got %T", dest)
+	}
+
+	rs, err := newRowScanner(rows, elem, opts)
+	if err != nil {
+		return err
+	}
+
+	slice.SetLen(0)
+	for rows.Next() {
+		nv := reflect.New(elem)
+		if err := rs.scan(rows, nv); err != nil {
+			return err
+		}
+		if isPtr {
+			slice.Set(reflect.Append(slice, nv))
+		} else {
+			slice.Set(reflect.Append(slice, nv.Elem()))
+		}
+	}
+	return rows.Err()
+}
+
+// ScanOne scans the first row of a raw query into dest, which must be a pointer
+// to a struct, and closes rows. ErrNotFound is returned when there is no row.
+//
+// Columns are matched to fields as in ScanAll.
+func ScanOne(rows *sql.Rows, dest interface{}, opts ...ScanOption) error {
+	defer rows.Close()
+
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("dorm: ScanOne needs a pointer to a struct, got %T", dest)
+	}
+
+	rs, err := newRowScanner(rows, v.Elem().Type(), opts)
+	if err != nil {
+		return err
+	}
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return ErrNotFound
+	}
+	if err := rs.scan(rows, v); err != nil {
+		return err
+	}
+	return rows.Err()
+}
+
+// rowScanner holds, for each result column, the index path of the field it
+// is scanned into. A nil path discards the column.
+type rowScanner struct {
+	paths [][]int
+}
+
+func newRowScanner(rows *sql.Rows, t reflect.Type, opts []ScanOption) (*rowScanner, error) {
+	var o scanOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	index := columnIndex(t)
+	rs := &rowScanner{paths: make([][]int, len(cols))}
+	for i, col := range cols {
+		path, ok := index[col]
+		if !ok && o.strict {
+			return nil, fmt.Errorf("dorm: column %q has no matching field in %s", col, t)
+		}
+		rs.paths[i] = path
+	}
+	return rs, nil
+}
+
+// scan scans the current row into the struct pointed to by dest.
+func (rs *rowScanner) scan(rows *sql.Rows, dest reflect.Value) error {
+	var refs = make([]interface{}, len(rs.paths))
+	for i, path := range rs.paths {
+		if path == nil {
+			refs[i] = new(interface{})
+			continue
+		}
+		refs[i] = fieldByIndex(dest.Elem(), path, true).Addr().Interface()
+	}
+	return rows.Scan(refs...)
+}
+
+// scanRows scans every remaining row into a new R, matching columns by name.
+// R must be a struct.
+func scanRows[R any](rows *sql.Rows) ([]R, error) {
+	rs, err := newRowScanner(rows, reflect.TypeOf((*R)(nil)).Elem(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf = make([]R, 0)
+	for rows.Next() {
+		var r R
+		if err := rs.scan(rows, reflect.ValueOf(&r)); err != nil {
+			return nil, err
+		}
+		buf = append(buf, r)
+	}
+	return buf, rows.Err()
+}
@@ -0,0 +1,86 @@
+package dorm
+
+import (
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type audit struct {
+	CreatedBy string `orm:"created_by"`
+}
+
+// userRank embeds a struct whose fields are matched too.
+type userRank struct {
+	audit
+	Id    int64
+	Email string `orm:"email"`
+	Rank  int64  `orm:"rank"`
+}
+
+func TestScanAll(t *testing.T) {
+	columns := []string{"rank", "email", "id", "created_by", "score"}
+	values := [][]driver.Value{{int64(1), "bo@x", int64(7), "al", 9.5}, {int64(2), "cy@x", int64(8), "al", 3.0}}
+	want := []userRank{{audit{"al"}, 7, "bo@x", 1}, {audit{"al"}, 8, "cy@x", 2}}
+
+	tests := []struct {
+		name string
+		dest interface{}
+		opts []ScanOption
+		want interface{}
+		err  bool
+	}{
+		{name: "structs", dest: &[]userRank{}, want: &want},
+		{name: "pointers", dest: &[]*userRank{}, want: &[]*userRank{&want[0], &want[1]}},
+		{name: "existing elements are replaced", dest: &[]userRank{{Id: 1}}, want: &want},
+		{name: "strict", dest: &[]userRank{}, opts: []ScanOption{Strict()}, err: true},
+		{name: "not a pointer", dest: []userRank{}, err: true},
+		{name: "not structs", dest: &[]int64{}, err: true},
+	}
+	for _, tt := range tests {
+		f, _ := openFake(t, "mysql")
+		f.answer(columns, values...)
+		rows, err := f.db.Query("SELECT rank, email, id, created_by, score FROM ranked")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = ScanAll(rows, tt.dest, tt.opts...)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s: ScanAll() succeeded", tt.name)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(tt.dest, tt.want) {
+			t.Errorf("%s: ScanAll() = %v, %+v, want %+v", tt.name, err, tt.dest, tt.want)
+		}
+	}
+}
+
+func TestScanOne(t *testing.T) {
+	f, _ := openFake(t, "mysql")
+	f.answer([]string{"id", "email"}, []driver.Value{int64(7), "bo@x"}, []driver.Value{int64(8), "cy@x"})
+	rows, _ := f.db.Query("SELECT id, email FROM users")
+	var u userRank
+	if err := ScanOne(rows, &u, Strict()); err != nil || u.Id != 7 || u.Email != "bo@x" {
+		t.Errorf("ScanOne() = %v, %+v", err, u)
+	}
+
+	rows, _ = f.db.Query("SELECT id FROM users WHERE 0")
+	if err := ScanOne(rows, &u); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ScanOne() of no row = %v, want ErrNotFound", err)
+	}
+
+	f.answer([]string{"id", "unknown"}, []driver.Value{int64(7), "x"})
+	rows, _ = f.db.Query("SELECT id, unknown FROM users")
+	if err := ScanOne(rows, &u, Strict()); err == nil {
+		t.Error("ScanOne() of an unmatched column succeeded in strict mode")
+	}
+
+	rows, _ = f.db.Query("SELECT id FROM users")
+	if err := ScanOne(rows, u); err == nil {
+		t.Error("ScanOne() into a struct value succeeded")
+	}
+}
@@ -0,0 +1,4 @@
+// Package sqlitetest runs the dorm tests that need a real database, on
+// in-memory SQLite databases. It has no code of its own: it is a module apart
+// so that the library does not depend on go-sqlite3, which needs cgo.
+package sqlitetest
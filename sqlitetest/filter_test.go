@@ -0,0 +1,64 @@
+package sqlitetest
+
+import (
+	"testing"
+
+	"github.com/dengsibao/dorm"
+)
+
+type likeRecord struct {
+	Id   int64  `orm:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Name string `orm:"name"`
+}
+
+type likeFilter struct {
+	Name    string `filter:"name,like"`
+	Pattern string `filter:"name,pattern"`
+}
+
+func TestFilterLike(t *testing.T) {
+	db, d := openSqlite(t)
+	d.Bind("records", &likeRecord{})
+	if _, err := db.Exec(d.GetSchema()); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"50% off", "500 off", "a_b", "axb", "x!y", `c:\d`} {
+		if err := dorm.New(d.DB(), "sqlite3").Bind("records", &likeRecord{Name: name}).Insert(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter likeFilter
+		want   []string
+	}{
+		{"like takes % literally", likeFilter{Name: "50%"}, []string{"50% off"}},
+		{"like takes _ literally", likeFilter{Name: "a_b"}, []string{"a_b"}},
+		{"like takes the escape character literally", likeFilter{Name: "!"}, []string{"x!y"}},
+		{"like takes a backslash literally", likeFilter{Name: `\`}, []string{`c:\d`}},
+		{"like matches anywhere", likeFilter{Name: "off"}, []string{"50% off", "500 off"}},
+		{"pattern keeps wildcards", likeFilter{Pattern: "50%"}, []string{"50% off", "500 off"}},
+		{"pattern is anchored", likeFilter{Pattern: "a_"}, nil},
+	}
+	for _, tt := range tests {
+		list, err := dorm.ListWhere(d, nil, dorm.FilterFrom(d, &tt.filter).Apply)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var got []string
+		for _, r := range list {
+			got = append(got, r.Interface().(*likeRecord).Name)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,17 @@
+module github.com/dengsibao/dorm/sqlitetest
+
+go 1.18
+
+require (
+	github.com/Masterminds/squirrel v1.5.2
+	github.com/dengsibao/dorm v0.0.0-00010101000000-000000000000
+	github.com/mattn/go-sqlite3 v1.14.19
+)
+
+require (
+	github.com/lann/builder v0.0.0-20180802200727-47ae307949d0 // indirect
+	github.com/lann/ps v0.0.0-20150810152359-62de8c46ede0 // indirect
+)
+
+// The tests run against the library in the parent directory.
+replace github.com/dengsibao/dorm => ../
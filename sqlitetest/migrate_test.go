@@ -0,0 +1,68 @@
+package sqlitetest
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/dengsibao/dorm"
+	"github.com/dengsibao/dorm/migrate"
+)
+
+func TestMigrate(t *testing.T) {
+	db, _ := openSqlite(t)
+	files := fstest.MapFS{
+		"0001_create_notes.up.sql":   {Data: []byte(`create table notes (id integer primary key, body text);`)},
+		"0001_create_notes.down.sql": {Data: []byte(`drop table notes;`)},
+		"0002_add_seen.up.sql":       {Data: []byte(`alter table notes add column seen datetime;`)},
+	}
+	m, err := migrate.New(db, "sqlite3", files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`insert into notes (body, seen) values ('a', '2024-05-01 12:00:00')`); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Down(1); err == nil {
+		t.Error("Down() without a down file succeeded")
+	}
+
+	st, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st) != 2 || !st[0].Applied || !st[1].Applied {
+		t.Errorf("Status() = %+v", st)
+	}
+}
+
+func TestMigrateAppliedAtIsUTC(t *testing.T) {
+	defer func(l *time.Location) { dorm.TimeLocation = l }(dorm.TimeLocation)
+	dorm.TimeLocation = time.FixedZone("UTC+8", 8*3600)
+
+	// applied_at as a plain text column: the driver returns the string
+	// CURRENT_TIMESTAMP wrote, in UTC
+	db, _ := openSqlite(t)
+	if _, err := db.Exec(`create table schema_migrations (version bigint not null primary key,
+		name varchar(255) not null default '', applied_at text not null default CURRENT_TIMESTAMP)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`insert into schema_migrations (version, name, applied_at) values (1, 'init', '2024-05-01 12:00:00')`); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := migrate.New(db, "sqlite3", fstest.MapFS{"0001_init.up.sql": {Data: []byte(`select 1;`)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC); len(st) != 1 || !st[0].AppliedAt.Time.Equal(want) {
+		t.Errorf("Status() = %+v, want applied at %v", st, want)
+	}
+}
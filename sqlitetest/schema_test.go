@@ -0,0 +1,133 @@
+package sqlitetest
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/dengsibao/dorm"
+)
+
+// schemaRecord has a field of every type GetSchema knows.
+type schemaRecord struct {
+	dorm.Model
+	Name     string                    `orm:"name" length:"32"`
+	Nick     *string                   `orm:"nick,NULL"`
+	Note     sql.NullString            `orm:"note,NULL"`
+	Age      int                       `orm:"age"`
+	Score    float64                   `orm:"score"`
+	Active   bool                      `orm:"active"`
+	Price    dorm.Decimal              `orm:"price" precision:"10" scale:"2"`
+	Discount dorm.NullDecimal          `orm:"discount,NULL"`
+	Seen     dorm.Time                 `orm:"seen"`
+	LeftAt   dorm.Time                 `orm:"left_at,NULL"`
+	Born     dorm.Date                 `orm:"born,NULL"`
+	Opens    dorm.TimeOfDay            `orm:"opens,NULL"`
+	Tags     dorm.Strings              `orm:"tags"`
+	Ids      dorm.Int64s               `orm:"ids"`
+	Meta     dorm.JSON[map[string]int] `orm:"meta"`
+}
+
+func TestGetSchemaRunsOnSqlite(t *testing.T) {
+	db, d := openSqlite(t)
+	d.Bind("records", &schemaRecord{})
+	if _, err := db.Exec(d.GetSchema()); err != nil {
+		t.Fatalf("%v\n%s", err, d.GetSchema())
+	}
+
+	var in = &schemaRecord{Name: "bo", Age: 7, Active: true, Price: dorm.MustDecimal("19.99"), Tags: dorm.Strings{"a"},
+		Seen: dorm.NewTime(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))}
+	in.Meta.Data = map[string]int{"x": 1}
+	var nick = "b"
+	in.Nick = &nick
+	if err := dorm.New(d.DB(), "sqlite3").Bind("records", in).Insert(); err != nil {
+		t.Fatal(err)
+	}
+
+	var out = &schemaRecord{Model: dorm.Model{Id: in.Id}}
+	if err := dorm.New(d.DB(), "sqlite3").Bind("records", out).Load(); err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != "bo" || out.Age != 7 || !out.Active || out.Price.String() != "19.99" || out.Meta.Data["x"] != 1 {
+		t.Errorf("loaded %+v", out)
+	}
+}
+
+func TestInsertUnsetTime(t *testing.T) {
+	db, d := openSqlite(t)
+	d.Bind("records", &schemaRecord{})
+	if _, err := db.Exec(d.GetSchema()); err != nil {
+		t.Fatal(err)
+	}
+	// Seen is left NULL, as before validation existed
+	if err := dorm.New(d.DB(), "sqlite3").Bind("records", &schemaRecord{Name: "bo"}).Insert(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type softRecord struct {
+	dorm.Model
+	Name      string    `orm:"name"`
+	DeletedAt dorm.Time `orm:"deleted_at,NULL"`
+}
+
+func TestModelDeletedAt(t *testing.T) {
+	tests := []struct {
+		name   string
+		record dorm.Record
+		schema string
+	}{
+		{
+			name:   "Model alone does not map deleted_at",
+			record: &struct{ dorm.Model }{},
+			schema: `create table records (id integer primary key autoincrement, created_at datetime, updated_at datetime, deleted boolean)`,
+		},
+		{
+			name:   "a DeletedAt field maps it",
+			record: &softRecord{},
+			schema: `create table records (id integer primary key autoincrement, created_at datetime, updated_at datetime,
+				deleted boolean, name text not null default '', deleted_at datetime)`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, d := openSqlite(t)
+			if _, err := db.Exec(tt.schema); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := db.Exec(`insert into records (created_at, updated_at, deleted) values ('2024-05-01 12:00:00', '2024-05-01 12:00:00', 0)`); err != nil {
+				t.Fatal(err)
+			}
+			r := d.Bind("records", tt.record)
+			list, err := dorm.List(r, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(list) != 1 {
+				t.Fatalf("List() returned %d records", len(list))
+			}
+			if n, err := dorm.DeleteWhere(r, dorm.AllRows); err != nil || n != 1 {
+				t.Fatalf("DeleteWhere() = %d, %v", n, err)
+			}
+		})
+	}
+
+	db, d := openSqlite(t)
+	if _, err := db.Exec(tests[1].schema); err != nil {
+		t.Fatal(err)
+	}
+	var in = &softRecord{Name: "bo"}
+	if err := dorm.New(d.DB(), "sqlite3").Bind("records", in).Insert(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dorm.DeleteWhere(dorm.New(d.DB(), "sqlite3").Bind("records", &softRecord{}), dorm.AllRows); err != nil {
+		t.Fatal(err)
+	}
+	var out = &softRecord{Model: dorm.Model{Id: in.Id}}
+	if err := dorm.New(d.DB(), "sqlite3").Bind("records", out).Load(); err != nil {
+		t.Fatal(err)
+	}
+	if !out.Deleted || !out.DeletedAt.Valid {
+		t.Errorf("after DeleteWhere: deleted %v, deleted_at %v", out.Deleted, out.DeletedAt)
+	}
+}
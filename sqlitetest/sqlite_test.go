@@ -0,0 +1,23 @@
+package sqlitetest
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/dengsibao/dorm"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// openSqlite returns a DbRecorder on a new in-memory sqlite database.
+func openSqlite(t *testing.T) (*sql.DB, *dorm.DbRecorder) {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every connection would get its own in-memory database
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db, dorm.New(squirrel.NewStmtCacheProxy(db), "sqlite3")
+}
@@ -0,0 +1,103 @@
+package sqlitetest
+
+import (
+	"testing"
+
+	"github.com/dengsibao/dorm"
+	"github.com/dengsibao/dorm/dormtest"
+)
+
+type verifyRecord struct {
+	Id    int64     `orm:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Count int       `orm:"count"`
+	Name  string    `orm:"name" length:"16"`
+	Nick  string    `orm:"nick,NULL" length:"16"`
+	Seen  dorm.Time `orm:"seen"`
+}
+
+func TestVerifySchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   []dorm.SchemaIssue
+	}{
+		{
+			name:   "as GetSchema creates it",
+			schema: "",
+		},
+		{
+			name: "NOT NULL columns of a generated struct",
+			schema: `create table verify (id integer primary key autoincrement, count int not null,
+				name varchar(16) not null, nick varchar(16), seen datetime not null)`,
+		},
+		{
+			name: "nullability mismatches",
+			schema: `create table verify (id integer primary key autoincrement, count int,
+				name varchar(16), nick varchar(16) not null, seen datetime)`,
+			want: []dorm.SchemaIssue{
+				{Table: "verify", Column: "name", Kind: dorm.NullMismatch, Expected: "NOT NULL", Actual: "NULL"},
+				{Table: "verify", Column: "nick", Kind: dorm.NullMismatch, Expected: "NULL", Actual: "NOT NULL"},
+			},
+		},
+		{
+			name:   "missing and extra columns",
+			schema: `create table verify (id integer primary key autoincrement, count bigint, name text not null, nick text, extra int)`,
+			want: []dorm.SchemaIssue{
+				{Table: "verify", Column: "seen", Kind: dorm.MissingColumn, Expected: "datetime default CURRENT_TIMESTAMP"},
+				{Table: "verify", Column: "extra", Kind: dorm.ExtraColumn, Actual: "int"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, d := openSqlite(t)
+			d.Bind("verify", &verifyRecord{})
+			var schema = tt.schema
+			if schema == "" {
+				schema = d.GetSchema()
+			}
+			if _, err := db.Exec(schema); err != nil {
+				t.Fatal(err)
+			}
+
+			report, err := dorm.VerifySchema(d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(report.Issues) != len(tt.want) {
+				t.Fatalf("issues = %v, want %v", report.Issues, tt.want)
+			}
+			for i, issue := range report.Issues {
+				if issue != tt.want[i] {
+					t.Errorf("issue %d = %#v, want %#v", i, issue, tt.want[i])
+				}
+			}
+			if report.OK() != (len(tt.want) == 0) {
+				t.Errorf("OK() = %v", report.OK())
+			}
+		})
+	}
+}
+
+// auditedRecorder wraps a DbRecorder, as an application might.
+type auditedRecorder struct {
+	dorm.DbRecorder
+}
+
+func TestVerifySchemaWrappers(t *testing.T) {
+	db, d := openSqlite(t)
+	d.Bind("verify", &verifyRecord{})
+	if _, err := db.Exec(d.GetSchema()); err != nil {
+		t.Fatal(err)
+	}
+
+	var w = &auditedRecorder{*dorm.New(d.DB(), "sqlite3")}
+	w.Bind("verify", &verifyRecord{})
+	if report, err := dorm.VerifySchema(w); err != nil || !report.OK() {
+		t.Errorf("VerifySchema() of a wrapper = %v, %v", report, err)
+	}
+
+	if _, err := dorm.VerifySchema(dormtest.NewStore().Bind("verify", &verifyRecord{})); err == nil {
+		t.Error("VerifySchema() of a Recorder without a connection succeeded")
+	}
+}
@@ -0,0 +1,349 @@
+package dorm
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// FieldError is a field of a Record failing validation.
+type FieldError struct {
+	// Field is the struct field name, Column the table column.
+	Field, Column string
+	// Rule is the failed check: length, null, enum, min, max or regexp.
+	Rule string
+	// Message describes the failure.
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Field + " " + e.Message
+}
+
+// ValidationError lists the fields of a Record that failed validation. It
+// matches ErrValidation with errors.Is:
+//
+// 	var ve *dorm.ValidationError
+// 	if errors.As(err, &ve) {
+// 		for _, f := range ve.Fields {
+// 			log.Printf("%s: %s", f.Column, f.Message)
+// 		}
+// 	}
+type ValidationError struct {
+	Table  string
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Error()
+	}
+	return fmt.Sprintf("%v on %s: %s", ErrValidation, e.Table, strings.Join(msgs, "; "))
+}
+
+// Is reports whether target is ErrValidation.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// rule is a check from the validate tag.
+type rule struct {
+	name, arg string
+}
+
+// nullability says whether the column of a field takes NULL.
+type nullability int
+
+const (
+	// anyNullable columns are left nullable by GetSchema, without the field
+	// asking for it: the database may have them either way.
+	anyNullable nullability = iota
+	// nullable columns are asked for by the NULL tag.
+	nullable
+	// notNullable columns are keys, or have a definition saying NOT NULL.
+	notNullable
+)
+
+// nullability returns whether the column of f takes NULL, as GetSchema
+// creates it. Validate and VerifySchema both go by it.
+func (f *field) nullability() nullability {
+	switch {
+	case f.isKey || strings.Contains(strings.ToLower(f.columnType), "not null"):
+		return notNullable
+	case f.isNull:
+		return nullable
+	}
+	return anyNullable
+}
+
+// Validate checks the bound Record against the constraints described by its
+// tags, and returns a *ValidationError listing every field that fails them:
+//
+//   - strings must fit the varchar length, from the length tag, columnDefinition
+//     or the varchar(255) default
+//   - fields must not hold a NULL value (an invalid sql.Null* for instance)
+//     when their column is NOT NULL: keys, and columns whose definition says
+//     so, such as the strings and bools of GetSchema
+//   - fields with an enum tag, or an enum(...) columnDefinition, must hold one
+//     of the listed values
+//   - the validate tag adds min=n and max=n (the value of numbers, the length
+//     of strings) and regexp=expr, which takes the rest of the tag:
+//
+// 	Age  int    `orm:"age" validate:"min=0,max=150"`
+// 	Code string `orm:"code" length:"8" validate:"regexp=^[A-Z]{2}[0-9]+$"`
+//
+// Nil pointers are not written by Insert or Update, so they are not checked.
+// Insert and Update call Validate before sending anything to the database.
+func (s *DbRecorder) Validate() error {
+	ar := reflect.Indirect(reflect.ValueOf(s.record))
+
+	var errs []FieldError
+	for _, f := range s.fields {
+		if f.isAuto {
+			continue
+		}
+		fv := fieldByIndex(ar, f.index, false)
+		if !fv.IsValid() || !fv.CanInterface() {
+			continue
+		}
+		fe, err := f.validate(fv)
+		if err != nil {
+			return err
+		}
+		errs = append(errs, fe...)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Table: s.table, Fields: errs}
+}
+
+func (f *field) validate(v reflect.Value) ([]FieldError, error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+
+	val, err := fieldValue(v)
+	if err != nil {
+		return []FieldError{f.fail("value", err.Error())}, nil
+	}
+
+	if val == nil {
+		if f.nullability() == notNullable {
+			return []FieldError{f.fail("null", "must not be NULL")}, nil
+		}
+		return nil, nil
+	}
+
+	var errs []FieldError
+	str, isStr := val.(string)
+	if isStr && f.maxLength > 0 && utf8.RuneCountInString(str) > f.maxLength {
+		errs = append(errs, f.fail("length", fmt.Sprintf("is longer than %d characters", f.maxLength)))
+	}
+
+	if len(f.enum) > 0 {
+		var s = fmt.Sprint(val)
+		var found = false
+		for _, e := range f.enum {
+			if e == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errs = append(errs, f.fail("enum", fmt.Sprintf("must be one of %s", strings.Join(f.enum, ", "))))
+		}
+	}
+
+	for _, r := range f.rules {
+		fe, err := f.check(r, val)
+		if err != nil {
+			return nil, err
+		}
+		if fe != nil {
+			errs = append(errs, *fe)
+		}
+	}
+	return errs, nil
+}
+
+// check applies a validate rule to a value. An error means the rule itself is
+// malformed.
+func (f *field) check(r rule, val interface{}) (*FieldError, error) {
+	switch r.name {
+	case "min", "max":
+		limit, err := strconv.ParseFloat(r.arg, 64)
+		if err != nil {
+			return nil, fmt.Errorf("dorm: bad %s rule %q on %s", r.name, r.arg, f.name)
+		}
+
+		var n float64
+		var what = ""
+		switch x := val.(type) {
+		case string:
+			n, what = float64(utf8.RuneCountInString(x)), " characters"
+		case int64:
+			n = float64(x)
+		case uint64:
+			n = float64(x)
+		case float64:
+			n = x
+		default:
+			return nil, nil
+		}
+
+		switch {
+		case r.name == "min" && n < limit:
+			fe := f.fail("min", fmt.Sprintf("must be at least %s%s", r.arg, what))
+			return &fe, nil
+		case r.name == "max" && n > limit:
+			fe := f.fail("max", fmt.Sprintf("must be at most %s%s", r.arg, what))
+			return &fe, nil
+		}
+		return nil, nil
+
+	case "regexp":
+		re, err := compileRule(r.arg)
+		if err != nil {
+			return nil, fmt.Errorf("dorm: bad regexp rule on %s: %v", f.name, err)
+		}
+		if s, ok := val.(string); ok && !re.MatchString(s) {
+			fe := f.fail("regexp", fmt.Sprintf("must match %s", r.arg))
+			return &fe, nil
+		}
+		return nil, nil
+
+	default:
+		return nil, fmt.Errorf("dorm: unknown validate rule %q on %s", r.name, f.name)
+	}
+}
+
+func (f *field) fail(rule, msg string) FieldError {
+	return FieldError{Field: f.name, Column: f.column, Rule: rule, Message: msg}
+}
+
+var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+// fieldValue returns the value of a field as validated: nil, string, int64,
+// uint64, float64 (decimals too), or anything else, which is only checked for NULL.
+func fieldValue(v reflect.Value) (interface{}, error) {
+	switch x := v.Interface().(type) {
+	case Decimal:
+		return x.Float64(), nil
+	case NullDecimal:
+		if !x.Valid {
+			return nil, nil
+		}
+		return x.Decimal.Float64(), nil
+	}
+	if v.Type().Implements(valuerType) {
+		return driverValue(v.Interface().(driver.Valuer))
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(valuerType) {
+		return driverValue(v.Addr().Interface().(driver.Valuer))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	}
+	return v.Interface(), nil
+}
+
+func driverValue(vr driver.Valuer) (interface{}, error) {
+	val, err := vr.Value()
+	if b, ok := val.([]byte); ok {
+		return string(b), err
+	}
+	return val, err
+}
+
+// columnLength reads the length of a char or varchar column definition.
+func columnLength(def string) int {
+	def = strings.ToLower(strings.TrimSpace(def))
+	for _, prefix := range []string{"varchar(", "char(", "character varying("} {
+		if strings.HasPrefix(def, prefix) {
+			n, _ := strconv.Atoi(strings.TrimSpace(strings.SplitN(def[len(prefix):], ")", 2)[0]))
+			return n
+		}
+	}
+	return 0
+}
+
+// parseEnum returns the values of an enum tag, or those of an enum('a','b')
+// column definition.
+func parseEnum(tag, def string) []string {
+	if tag != "" {
+		values := strings.Split(tag, ",")
+		for i := range values {
+			values[i] = strings.TrimSpace(values[i])
+		}
+		return values
+	}
+
+	def = strings.TrimSpace(def)
+	if !strings.HasPrefix(strings.ToLower(def), "enum(") {
+		return nil
+	}
+	end := strings.LastIndex(def, ")")
+	if end < 0 {
+		return nil
+	}
+
+	var values []string
+	for _, v := range strings.Split(def[len("enum("):end], ",") {
+		v = strings.TrimSpace(v)
+		values = append(values, strings.ReplaceAll(strings.Trim(v, "'"), "''", "'"))
+	}
+	return values
+}
+
+// parseRules parses a validate tag. A regexp rule takes the rest of the tag, so
+// the expression may hold commas.
+func parseRules(tag string) []rule {
+	var rules []rule
+	for tag = strings.TrimSpace(tag); tag != ""; tag = strings.TrimSpace(tag) {
+		var part string
+		if strings.HasPrefix(tag, "regexp=") {
+			part, tag = tag, ""
+		} else if i := strings.Index(tag, ","); i >= 0 {
+			part, tag = tag[:i], tag[i+1:]
+		} else {
+			part, tag = tag, ""
+		}
+
+		name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+		rules = append(rules, rule{name: name, arg: arg})
+	}
+	return rules
+}
+
+var ruleRegexps sync.Map
+
+func compileRule(expr string) (*regexp.Regexp, error) {
+	if re, ok := ruleRegexps.Load(expr); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	ruleRegexps.Store(expr, re)
+	return re, nil
+}
@@ -0,0 +1,82 @@
+package dorm
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type validateRecord struct {
+	Id     int64          `orm:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Code   string         `orm:"code" length:"4"`
+	Seen   Time           `orm:"seen"`
+	Note   sql.NullString `orm:"note"`
+	Strict sql.NullString `orm:"strict" columnDefinition:"varchar(8) not null"`
+	Status string         `orm:"status" enum:"new,done"`
+	Age    int            `orm:"age" validate:"min=0,max=150"`
+}
+
+func TestValidate(t *testing.T) {
+	valid := func() *validateRecord {
+		return &validateRecord{Code: "ab", Strict: sql.NullString{String: "x", Valid: true}, Status: "new", Age: 30}
+	}
+	tests := []struct {
+		name  string
+		edit  func(r *validateRecord)
+		rules []string
+	}{
+		{"valid", func(r *validateRecord) {}, nil},
+		{"unset time in a nullable column", func(r *validateRecord) { r.Seen = Time{} }, nil},
+		{"NULL in a nullable column", func(r *validateRecord) { r.Note = sql.NullString{} }, nil},
+		{"NULL in a NOT NULL column", func(r *validateRecord) { r.Strict = sql.NullString{} }, []string{"null"}},
+		{"too long", func(r *validateRecord) { r.Code = "abcde" }, []string{"length"}},
+		{"not in enum", func(r *validateRecord) { r.Status = "gone" }, []string{"enum"}},
+		{"below min", func(r *validateRecord) { r.Age = -1 }, []string{"min"}},
+		{"several", func(r *validateRecord) { r.Code, r.Age = "abcde", 151 }, []string{"length", "max"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := valid()
+			tt.edit(r)
+			err := New(nil, "mysql").Bind("records", r).(*DbRecorder).Validate()
+
+			var ve *ValidationError
+			if len(tt.rules) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() = %v", err)
+				}
+				return
+			}
+			if !errors.As(err, &ve) || !errors.Is(err, ErrValidation) {
+				t.Fatalf("Validate() = %v, want a *ValidationError", err)
+			}
+			if len(ve.Fields) != len(tt.rules) {
+				t.Fatalf("Validate() = %v, want rules %v", err, tt.rules)
+			}
+			for i, f := range ve.Fields {
+				if f.Rule != tt.rules[i] {
+					t.Errorf("rule %d = %s, want %s", i, f.Rule, tt.rules[i])
+				}
+			}
+		})
+	}
+}
+
+type noteRecord struct {
+	Id   int64  `orm:"id,PRIMARY_KEY,AUTO_INCREMENT"`
+	Body string `orm:"body"`
+}
+
+func TestInsertUntaggedLongString(t *testing.T) {
+	// body is a TEXT column: without a length tag, the varchar(255) GetSchema
+	// would pick is no limit
+	f, d := openFake(t, "mysql")
+	var body = strings.Repeat("x", 300)
+	if err := d.Bind("notes", &noteRecord{Body: body}).Insert(); err != nil {
+		t.Fatalf("Insert() = %v", err)
+	}
+	if _, args := f.last(); len(args) != 1 || args[0] != body {
+		t.Errorf("Insert() args = %v", args)
+	}
+}
@@ -0,0 +1,243 @@
+package dorm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SchemaIssueKind classifies a SchemaIssue.
+type SchemaIssueKind string
+
+const (
+	// MissingTable: the table of a Record does not exist.
+	MissingTable SchemaIssueKind = "missing table"
+	// MissingColumn: a field has no column; loading the Record fails.
+	MissingColumn SchemaIssueKind = "missing column"
+	// ExtraColumn: a column has no field; inserts fail if it is NOT NULL
+	// without a default.
+	ExtraColumn SchemaIssueKind = "extra column"
+	// TypeMismatch: the column type does not hold the values of the field.
+	TypeMismatch SchemaIssueKind = "type mismatch"
+	// NullMismatch: the column and the field disagree on NULL.
+	NullMismatch SchemaIssueKind = "nullability mismatch"
+	// MissingIndex: a PRIMARY_KEY or UNIQUE field has no such index.
+	MissingIndex SchemaIssueKind = "missing index"
+)
+
+// SchemaIssue is a difference between a Record and its live table.
+type SchemaIssue struct {
+	Table  string
+	Column string
+	Kind   SchemaIssueKind
+	// Expected describes the column as the Record maps it, Actual as found;
+	// either is empty when it does not exist.
+	Expected, Actual string
+}
+
+func (i SchemaIssue) String() string {
+	var s = fmt.Sprintf("%s: %s", i.Table, i.Kind)
+	if i.Column != "" {
+		s += " " + i.Column
+	}
+	if i.Expected != "" || i.Actual != "" {
+		s += fmt.Sprintf(" (expected %s, found %s)", orNone(i.Expected), orNone(i.Actual))
+	}
+	return s
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+// SchemaReport lists the differences found by VerifySchema. It is an error
+// matching ErrSchemaDrift, see Err.
+type SchemaReport struct {
+	Issues []SchemaIssue
+}
+
+// OK reports whether no difference was found.
+func (r *SchemaReport) OK() bool {
+	return len(r.Issues) == 0
+}
+
+// Err returns the report as an error, or nil if it is OK:
+//
+// 	report, err := dorm.VerifySchema(db.Models()...)
+// 	if err == nil {
+// 		err = report.Err()
+// 	}
+// 	if err != nil {
+// 		log.Fatal(err)
+// 	}
+func (r *SchemaReport) Err() error {
+	if r.OK() {
+		return nil
+	}
+	return r
+}
+
+func (r *SchemaReport) Error() string {
+	msgs := make([]string, len(r.Issues))
+	for i, issue := range r.Issues {
+		msgs[i] = issue.String()
+	}
+	return fmt.Sprintf("%v: %s", ErrSchemaDrift, strings.Join(msgs, "; "))
+}
+
+// Is reports whether target is ErrSchemaDrift.
+func (r *SchemaReport) Is(target error) bool {
+	return target == ErrSchemaDrift
+}
+
+// VerifySchema compares the fields of each Recorder with its live table, read
+// with InspectColumns, and reports the missing and extra columns, the columns
+// whose type or nullability does not match the field, and the missing
+// PRIMARY KEY and UNIQUE indexes. It is meant to run at startup, to catch
+// struct tags that do not match production before any query does.
+//
+// Types are compared by family (integer, string, decimal, datetime...), since
+// each database spells them its own way; a varchar shorter than the length
+// of the field is a mismatch too. Columns are expected NOT NULL when their
+// definition says so, and nullable when the field is tagged NULL; other
+// columns may be either. Validate rejects NULL values by the same rule.
+//
+// The Recorders are DbRecorders, or types embedding one, which know the
+// definitions of their fields, on a connection; any other Recorder is an
+// error, as are failures to read the catalog. Differences are only in the
+// report.
+func VerifySchema(recorders ...Recorder) (*SchemaReport, error) {
+	var report = new(SchemaReport)
+	for _, d := range recorders {
+		v, ok := d.(schemaVerifier)
+		if !ok {
+			return nil, fmt.Errorf("dorm: cannot verify the schema of %T, which does not embed a DbRecorder", d)
+		}
+		if d.DB() == nil {
+			return nil, fmt.Errorf("dorm: cannot verify the schema of %s without a connection", d.TableName())
+		}
+		live, err := InspectColumns(d.DB(), d.Driver(), d.TableName())
+		if err != nil {
+			return nil, fmt.Errorf("dorm: inspecting %s: %w", d.TableName(), err)
+		}
+		report.Issues = append(report.Issues, v.verifySchema(live)...)
+	}
+	return report, nil
+}
+
+// schemaVerifier is implemented by DbRecorder, and promoted to the types
+// embedding it.
+type schemaVerifier interface {
+	verifySchema(live []ColumnInfo) []SchemaIssue
+}
+
+func (s *DbRecorder) verifySchema(live []ColumnInfo) []SchemaIssue {
+	if len(live) == 0 {
+		return []SchemaIssue{{Table: s.table, Kind: MissingTable}}
+	}
+
+	var issues []SchemaIssue
+	var byName = make(map[string]ColumnInfo, len(live))
+	for _, c := range live {
+		byName[strings.ToLower(c.Name)] = c
+	}
+
+	var mapped = make(map[string]bool, len(s.fields))
+	for _, f := range s.fields {
+		mapped[strings.ToLower(f.column)] = true
+		issue := func(kind SchemaIssueKind, expected, actual string) {
+			issues = append(issues, SchemaIssue{Table: s.table, Column: f.column, Kind: kind, Expected: expected, Actual: actual})
+		}
+
+		c, ok := byName[strings.ToLower(f.column)]
+		if !ok {
+			issue(MissingColumn, f.columnType, "")
+			continue
+		}
+
+		var expected = typeFamily(f.columnType)
+		if f.isAuto {
+			expected = "integer"
+		}
+		if actual := typeFamily(c.ColumnType); expected != actual && expected != "" && actual != "" {
+			issue(TypeMismatch, expected, c.ColumnType)
+		} else if f.maxLength > 0 && c.Length > 0 && c.Length < int64(f.maxLength) {
+			issue(TypeMismatch, fmt.Sprintf("length %d", f.maxLength), c.ColumnType)
+		}
+		if f.isAuto && !c.AutoIncrement {
+			issue(TypeMismatch, "auto increment", c.ColumnType)
+		}
+
+		switch null := f.nullability(); {
+		case f.isKey:
+		case null == notNullable && c.Nullable:
+			issue(NullMismatch, "NOT NULL", "NULL")
+		case null == nullable && !c.Nullable:
+			issue(NullMismatch, "NULL", "NOT NULL")
+		}
+
+		if f.isKey && !c.PrimaryKey {
+			issue(MissingIndex, "PRIMARY KEY", "")
+		}
+		if f.isUnique && !c.Unique && !c.PrimaryKey {
+			issue(MissingIndex, "UNIQUE", "")
+		}
+	}
+
+	for _, c := range live {
+		if !mapped[strings.ToLower(c.Name)] {
+			issues = append(issues, SchemaIssue{Table: s.table, Column: c.Name, Kind: ExtraColumn, Actual: c.ColumnType})
+		}
+	}
+	return issues
+}
+
+// typeFamily returns the family of a column type or definition, such as
+// "bigint default 0" or "character varying(64)": integer, boolean, string,
+// decimal, float, datetime, date, time, json or binary. Other types are their
+// own family; an empty definition has none.
+func typeFamily(def string) string {
+	def = strings.ToLower(strings.TrimSpace(def))
+	if strings.HasPrefix(def, "tinyint(1)") {
+		return "boolean"
+	}
+	if i := strings.IndexByte(def, '('); i >= 0 {
+		def = def[:i]
+	}
+	var words = strings.Fields(def)
+	switch {
+	case len(words) == 0:
+		return ""
+	case len(words) > 1 && (words[0] == "character" && words[1] == "varying" || words[0] == "double" && words[1] == "precision" ||
+		(words[0] == "timestamp" || words[0] == "time") && (words[1] == "with" || words[1] == "without")):
+		def = words[0] + " " + words[1]
+	default:
+		def = words[0]
+	}
+
+	switch def {
+	case "int", "integer", "tinyint", "smallint", "mediumint", "bigint", "int2", "int4", "int8",
+		"serial", "bigserial", "smallserial":
+		return "integer"
+	case "bool", "boolean":
+		return "boolean"
+	case "varchar", "char", "character", "character varying", "nvarchar", "nchar", "text",
+		"tinytext", "mediumtext", "longtext", "enum", "set", "uuid", "citext":
+		return "string"
+	case "decimal", "numeric":
+		return "decimal"
+	case "float", "double", "double precision", "real", "float4", "float8":
+		return "float"
+	case "datetime", "timestamp", "timestamptz", "timestamp with", "timestamp without":
+		return "datetime"
+	case "time", "timetz", "time with", "time without":
+		return "time"
+	case "json", "jsonb":
+		return "json"
+	case "blob", "tinyblob", "mediumblob", "longblob", "bytea", "binary", "varbinary":
+		return "binary"
+	}
+	return def
+}